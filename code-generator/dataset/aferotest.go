@@ -0,0 +1,443 @@
+// Copyright © 2017 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package aferotest checks that implementations of afero.Fs and afero.File
+// behave like the OS does, so that code written against the OsFs keeps
+// working against them. Implementations run the checks in their tests:
+//
+//	func TestConformance(t *testing.T) {
+//		aferotest.TestFs(t, NewMyFs())
+//	}
+package aferotest
+
+import (
+	"io"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// TestFs checks fs, which must be empty and writable: the errors returned
+// for missing and existing files, reading and writing files, paging
+// through directories, permission bits, renaming and removing.
+func TestFs(t *testing.T, fs afero.Fs) {
+	t.Run("NotExist", func(t *testing.T) { testNotExist(t, fs) })
+	t.Run("Exist", func(t *testing.T) { testExist(t, fs) })
+	t.Run("File", func(t *testing.T) {
+		f, err := fs.OpenFile("/file", os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer fs.Remove("/file")
+		TestFile(t, f)
+	})
+	t.Run("Readdir", func(t *testing.T) { testReaddir(t, fs) })
+	t.Run("Mode", func(t *testing.T) { testMode(t, fs) })
+	t.Run("Rename", func(t *testing.T) { testRename(t, fs) })
+	t.Run("Remove", func(t *testing.T) { testRemove(t, fs) })
+}
+
+// TestReadOnlyFs checks fs, which must refuse every change and hold the
+// files named names in the directory dir: the errors returned for missing
+// files and for changes, and paging through the directory.
+func TestReadOnlyFs(t *testing.T, fs afero.Fs, dir string, names []string) {
+	t.Run("NotExist", func(t *testing.T) {
+		const name = "/missing"
+		check := func(op string, err error) {
+			if !os.IsNotExist(err) {
+				t.Errorf("%s: expected an error satisfying os.IsNotExist, got %v", op, err)
+			}
+		}
+		_, err := fs.Stat(name)
+		check("Stat", err)
+		_, err = fs.Open(name)
+		check("Open", err)
+		_, err = fs.OpenFile(name, os.O_RDONLY, 0)
+		check("OpenFile", err)
+	})
+	t.Run("Permission", func(t *testing.T) {
+		name := path.Join(dir, names[0])
+		check := func(op string, err error) {
+			if !os.IsPermission(err) {
+				t.Errorf("%s: expected an error satisfying os.IsPermission, got %v", op, err)
+			}
+		}
+		_, err := fs.Create(path.Join(dir, "new"))
+		check("Create", err)
+		_, err = fs.OpenFile(name, os.O_RDWR, 0)
+		check("OpenFile", err)
+		check("Mkdir", fs.Mkdir(path.Join(dir, "newdir"), 0755))
+		check("MkdirAll", fs.MkdirAll(path.Join(dir, "newdir", "sub"), 0755))
+		check("Remove", fs.Remove(name))
+		check("RemoveAll", fs.RemoveAll(dir))
+		check("Rename", fs.Rename(name, path.Join(dir, "renamed")))
+		check("Chmod", fs.Chmod(name, 0600))
+		check("Chtimes", fs.Chtimes(name, time.Now(), time.Now()))
+		if _, err := fs.Stat(name); err != nil {
+			t.Errorf("Stat: expected the file to be kept, got %v", err)
+		}
+	})
+	t.Run("Readdir", func(t *testing.T) { TestDir(t, fs, dir, names) })
+}
+
+// TestUnionFs checks fs, a union of an empty writable layer over base,
+// which must be empty and writable and is seeded with files and
+// directories: that changes to the files and directories of base are
+// made in the layer, that base is left as it was, and that directories
+// list the entries of both. Removing and renaming files of base may fail
+// with an error satisfying os.IsPermission, or else must hide them.
+func TestUnionFs(t *testing.T, fs, base afero.Fs) {
+	if err := base.MkdirAll("/base/dir/sub", 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"/base/file", "/base/mode", "/base/dir/a", "/base/dir/b"} {
+		if err := afero.WriteFile(base, name, []byte("base"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkBase := func() {
+		if content, err := afero.ReadFile(base, "/base/file"); err != nil || string(content) != "base" {
+			t.Errorf("ReadFile: expected the base to be kept, got %q, %v", content, err)
+		}
+	}
+
+	t.Run("Exist", func(t *testing.T) {
+		if fi, err := fs.Stat("/base/dir"); err != nil || !fi.IsDir() {
+			t.Errorf("Stat: expected the directory of the base, got %v, %v", fi, err)
+		}
+		if err := fs.Mkdir("/base/dir", 0755); !os.IsExist(err) {
+			t.Errorf("Mkdir: expected an error satisfying os.IsExist, got %v", err)
+		}
+		if err := fs.MkdirAll("/base/dir/sub", 0755); err != nil {
+			t.Errorf("MkdirAll: expected no error, got %v", err)
+		}
+	})
+	t.Run("File", func(t *testing.T) {
+		f, err := fs.OpenFile("/base/file", os.O_WRONLY|os.O_APPEND, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write([]byte(" layer")); err != nil {
+			t.Errorf("Write: %v", err)
+		}
+		f.Close()
+		if content, err := afero.ReadFile(fs, "/base/file"); err != nil || string(content) != "base layer" {
+			t.Errorf("ReadFile: got %q, %v", content, err)
+		}
+		checkBase()
+	})
+	t.Run("Readdir", func(t *testing.T) {
+		if err := afero.WriteFile(fs, "/base/dir/c", []byte("layer"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		defer fs.Remove("/base/dir/c")
+		TestDir(t, fs, "/base/dir", []string{"a", "b", "c", "sub"})
+	})
+	t.Run("Mode", func(t *testing.T) {
+		mtime := time.Date(2017, 3, 4, 5, 6, 7, 0, time.UTC)
+		for _, name := range []string{"/base/mode", "/base/dir/sub"} {
+			if err := fs.Chmod(name, 0600); err != nil {
+				t.Errorf("Chmod %s: %v", name, err)
+			}
+			if err := fs.Chtimes(name, mtime, mtime); err != nil {
+				t.Errorf("Chtimes %s: %v", name, err)
+			}
+			fi, err := fs.Stat(name)
+			if err != nil {
+				t.Errorf("Stat: %v", err)
+				continue
+			}
+			if fi.Mode().Perm() != 0600 || !fi.ModTime().Equal(mtime) {
+				t.Errorf("Stat %s: expected the permissions %v and the time %v, got %v and %v", name, os.FileMode(0600), mtime, fi.Mode().Perm(), fi.ModTime())
+			}
+			if bfi, err := base.Stat(name); err != nil || bfi.Mode().Perm() == 0600 {
+				t.Errorf("Stat %s: expected the base to be kept, got %v, %v", name, bfi, err)
+			}
+		}
+		if fi, err := fs.Stat("/base/dir/sub"); err != nil || !fi.IsDir() {
+			t.Errorf("Stat: expected a directory, got %v, %v", fi, err)
+		}
+	})
+	t.Run("Remove", func(t *testing.T) {
+		checkGone := func(op, name string, err error) {
+			if os.IsPermission(err) {
+				if _, err := fs.Stat(name); err != nil {
+					t.Errorf("%s: expected the refused change to keep the file, got %v", op, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("%s: %v", op, err)
+			}
+			if _, err := fs.Stat(name); !os.IsNotExist(err) {
+				t.Errorf("Stat: expected the file to be hidden, got %v", err)
+			}
+			names, _ := afero.ReadDir(fs, "/base/dir")
+			for _, fi := range names {
+				if fi.Name() == path.Base(name) {
+					t.Errorf("ReadDir: expected the file to be hidden, got it listed")
+				}
+			}
+		}
+		checkGone("Remove", "/base/dir/a", fs.Remove("/base/dir/a"))
+		err := fs.Rename("/base/dir/b", "/base/renamed")
+		checkGone("Rename", "/base/dir/b", err)
+		if err == nil {
+			if content, err := afero.ReadFile(fs, "/base/renamed"); err != nil || string(content) != "base" {
+				t.Errorf("ReadFile: got %q, %v", content, err)
+			}
+		}
+		checkBase()
+	})
+}
+
+func testNotExist(t *testing.T, fs afero.Fs) {
+	const name = "/missing"
+	check := func(op string, err error) {
+		if !os.IsNotExist(err) {
+			t.Errorf("%s: expected an error satisfying os.IsNotExist, got %v", op, err)
+		}
+	}
+	_, err := fs.Stat(name)
+	check("Stat", err)
+	_, err = fs.Open(name)
+	check("Open", err)
+	_, err = fs.OpenFile(name, os.O_RDWR, 0)
+	check("OpenFile", err)
+	check("Remove", fs.Remove(name))
+	check("Rename", fs.Rename(name, "/renamed"))
+	check("Chmod", fs.Chmod(name, 0644))
+	check("Chtimes", fs.Chtimes(name, time.Now(), time.Now()))
+	if err := fs.RemoveAll(name); err != nil {
+		t.Errorf("RemoveAll: expected no error, got %v", err)
+	}
+}
+
+func testExist(t *testing.T, fs afero.Fs) {
+	if err := fs.Mkdir("/exist", 0755); err != nil {
+		t.Fatal(err)
+	}
+	defer fs.RemoveAll("/exist")
+	if err := fs.Mkdir("/exist", 0755); !os.IsExist(err) {
+		t.Errorf("Mkdir: expected an error satisfying os.IsExist, got %v", err)
+	}
+	if err := fs.MkdirAll("/exist", 0755); err != nil {
+		t.Errorf("MkdirAll: expected no error, got %v", err)
+	}
+}
+
+// TestFile checks f, which must be an empty file opened for reading and
+// writing: reading and writing it, at its offset and at given offsets,
+// seeking, truncating it and getting its size. TestFile closes f.
+func TestFile(t *testing.T, f afero.File) {
+	defer f.Close()
+
+	if n, err := f.Write([]byte("hello world")); n != 11 || err != nil {
+		t.Fatalf("Write: got %d, %v", n, err)
+	}
+	if pos, err := f.Seek(0, io.SeekCurrent); pos != 11 || err != nil {
+		t.Errorf("Seek: expected the offset after the write, got %d, %v", pos, err)
+	}
+
+	b := make([]byte, 5)
+	if n, err := f.ReadAt(b, 6); n != 5 || err != nil || string(b) != "world" {
+		t.Errorf("ReadAt: got %d, %q, %v", n, b[:n], err)
+	}
+	if n, err := f.ReadAt(b, 11); n != 0 || err != io.EOF {
+		t.Errorf("ReadAt: expected io.EOF at the end of the file, got %d, %v", n, err)
+	}
+	if n, err := f.WriteAt([]byte("W"), 6); n != 1 || err != nil {
+		t.Errorf("WriteAt: got %d, %v", n, err)
+	}
+
+	if pos, err := f.Seek(0, io.SeekStart); pos != 0 || err != nil {
+		t.Errorf("Seek: got %d, %v", pos, err)
+	}
+	if content, err := afero.ReadAll(f); err != nil || string(content) != "hello World" {
+		t.Errorf("Read: got %q, %v", content, err)
+	}
+	if n, err := f.Read(b); n != 0 || err != io.EOF {
+		t.Errorf("Read: expected io.EOF at the end of the file, got %d, %v", n, err)
+	}
+
+	if err := f.Truncate(5); err != nil {
+		t.Fatalf("Truncate: %v", err)
+	}
+	if fi, err := f.Stat(); err != nil || fi.Size() != 5 {
+		t.Errorf("Stat: expected the truncated size, got %v, %v", fi, err)
+	}
+	if pos, err := f.Seek(0, io.SeekEnd); pos != 5 || err != nil {
+		t.Errorf("Seek: expected the truncated size, got %d, %v", pos, err)
+	}
+	if pos, err := f.Seek(-2, io.SeekEnd); pos != 3 || err != nil {
+		t.Errorf("Seek: got %d, %v", pos, err)
+	}
+	if n, err := f.Read(b); n != 2 || err != nil || string(b[:n]) != "lo" {
+		t.Errorf("Read: got %d, %q, %v", n, b[:n], err)
+	}
+}
+
+func testReaddir(t *testing.T, fs afero.Fs) {
+	names := []string{"a", "b", "c", "d", "e"}
+	if err := fs.Mkdir("/dir", 0755); err != nil {
+		t.Fatal(err)
+	}
+	defer fs.RemoveAll("/dir")
+	for _, name := range names {
+		if err := afero.WriteFile(fs, "/dir/"+name, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	TestDir(t, fs, "/dir", names)
+}
+
+// TestDir checks that the directory dir of fs, which holds the files
+// named names, pages through its entries like os.File.Readdir and
+// os.File.Readdirnames do.
+func TestDir(t *testing.T, fs afero.Fs, dir string, names []string) {
+	want := append([]string(nil), names...)
+	sort.Strings(want)
+
+	f, err := fs.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for {
+		fis, err := f.Readdir(2)
+		if err == io.EOF {
+			if len(fis) != 0 {
+				t.Errorf("Readdir: got %d entries along with io.EOF", len(fis))
+			}
+			break
+		}
+		if err != nil {
+			t.Fatalf("Readdir: %v", err)
+		}
+		if len(fis) == 0 || len(fis) > 2 {
+			t.Fatalf("Readdir: got a page of %d entries", len(fis))
+		}
+		for _, fi := range fis {
+			got = append(got, fi.Name())
+		}
+	}
+	sort.Strings(got)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Readdir: got %v, want %v", got, want)
+	}
+	if fis, err := f.Readdir(-1); err != nil || len(fis) != 0 {
+		t.Errorf("Readdir: expected no more entries, got %d, %v", len(fis), err)
+	}
+	if _, err := f.Readdir(1); err != io.EOF {
+		t.Errorf("Readdir: expected io.EOF, got %v", err)
+	}
+	f.Close()
+
+	f, err = fs.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	got, err = f.Readdirnames(-1)
+	if err != nil {
+		t.Fatalf("Readdirnames: %v", err)
+	}
+	sort.Strings(got)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Readdirnames: got %v, want %v", got, want)
+	}
+	if _, err := f.Readdirnames(1); err != io.EOF {
+		t.Errorf("Readdirnames: expected io.EOF, got %v", err)
+	}
+}
+
+func testMode(t *testing.T, fs afero.Fs) {
+	check := func(name string, perm os.FileMode) {
+		fi, err := fs.Stat(name)
+		if err != nil {
+			t.Errorf("Stat: %v", err)
+			return
+		}
+		if fi.Mode().Perm() != perm {
+			t.Errorf("Stat %s: expected the permissions %v, got %v", name, perm, fi.Mode().Perm())
+		}
+	}
+
+	f, err := fs.OpenFile("/mode", os.O_RDWR|os.O_CREATE, 0640)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer fs.Remove("/mode")
+	check("/mode", 0640)
+	if err := fs.Chmod("/mode", 0600); err != nil {
+		t.Fatal(err)
+	}
+	check("/mode", 0600)
+
+	if err := fs.Mkdir("/modedir", 0750); err != nil {
+		t.Fatal(err)
+	}
+	defer fs.RemoveAll("/modedir")
+	check("/modedir", 0750)
+	if fi, err := fs.Stat("/modedir"); err != nil || !fi.IsDir() {
+		t.Errorf("Stat: expected a directory, got %v, %v", fi, err)
+	}
+
+	mtime := time.Date(2017, 3, 4, 5, 6, 7, 0, time.UTC)
+	if err := fs.Chtimes("/mode", mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	if fi, err := fs.Stat("/mode"); err != nil || !fi.ModTime().Equal(mtime) {
+		t.Errorf("Chtimes: expected the time %v, got %v, %v", mtime, fi.ModTime(), err)
+	}
+}
+
+func testRename(t *testing.T, fs afero.Fs) {
+	if err := afero.WriteFile(fs, "/old", []byte("content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.Rename("/old", "/new"); err != nil {
+		t.Fatal(err)
+	}
+	defer fs.Remove("/new")
+	if _, err := fs.Stat("/old"); !os.IsNotExist(err) {
+		t.Errorf("Stat: expected the old name to be gone, got %v", err)
+	}
+	if content, err := afero.ReadFile(fs, "/new"); err != nil || string(content) != "content" {
+		t.Errorf("ReadFile: got %q, %v", content, err)
+	}
+}
+
+func testRemove(t *testing.T, fs afero.Fs) {
+	if err := fs.MkdirAll("/tree/sub", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := afero.WriteFile(fs, "/tree/sub/file", []byte("content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.Remove("/tree/sub/file"); err != nil {
+		t.Errorf("Remove: %v", err)
+	}
+	if err := fs.RemoveAll("/tree"); err != nil {
+		t.Errorf("RemoveAll: %v", err)
+	}
+	if _, err := fs.Stat("/tree/sub"); !os.IsNotExist(err) {
+		t.Errorf("Stat: expected the tree to be gone, got %v", err)
+	}
+}
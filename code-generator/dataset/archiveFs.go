@@ -0,0 +1,269 @@
+package afero
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"syscall"
+	"time"
+)
+
+// archiveFs is the read only Fs of the files of an archive, which the
+// ZipFs and the TarFs index when they are created.
+type archiveFs struct {
+	files map[string]*archiveEntry
+}
+
+type archiveEntry struct {
+	info os.FileInfo
+	// names are the sorted names of the files of a directory
+	names []string
+	// content returns the content of a file
+	content func() ([]byte, error)
+}
+
+func newArchiveFs() *archiveFs {
+	a := &archiveFs{files: make(map[string]*archiveEntry)}
+	a.files["/"] = &archiveEntry{info: archiveDirInfo{name: "/"}}
+	return a
+}
+
+// archivePath converts the name of a file of an archive or of the Fs into
+// the key of its entry.
+func archivePath(name string) string {
+	return path.Clean("/" + filepath.ToSlash(name))
+}
+
+// add adds the file at name to the archive, along with the parent
+// directories which the archive does not hold.
+func (a *archiveFs) add(name string, entry *archiveEntry) {
+	name = archivePath(name)
+	if name == "/" {
+		return
+	}
+	if old, ok := a.files[name]; ok {
+		// a directory may be added after the files it holds
+		entry.names = old.names
+		a.files[name] = entry
+		return
+	}
+	a.files[name] = entry
+	for name != "/" {
+		dir := path.Dir(name)
+		parent, ok := a.files[dir]
+		if !ok {
+			parent = &archiveEntry{info: archiveDirInfo{name: path.Base(dir)}}
+			a.files[dir] = parent
+		}
+		parent.names = append(parent.names, path.Base(name))
+		if ok {
+			break
+		}
+		name = dir
+	}
+}
+
+func (a *archiveFs) sort() {
+	for _, entry := range a.files {
+		sort.Strings(entry.names)
+	}
+}
+
+func (a *archiveFs) lookup(op, name string) (*archiveEntry, error) {
+	entry, ok := a.files[archivePath(name)]
+	if !ok {
+		return nil, &os.PathError{Op: op, Path: name, Err: os.ErrNotExist}
+	}
+	return entry, nil
+}
+
+func (a *archiveFs) Create(name string) (File, error) {
+	return nil, &os.PathError{Op: "create", Path: name, Err: syscall.EPERM}
+}
+
+func (a *archiveFs) Mkdir(name string, perm os.FileMode) error {
+	return &os.PathError{Op: "mkdir", Path: name, Err: syscall.EPERM}
+}
+
+func (a *archiveFs) MkdirAll(path string, perm os.FileMode) error {
+	return &os.PathError{Op: "mkdir", Path: path, Err: syscall.EPERM}
+}
+
+func (a *archiveFs) Open(name string) (File, error) {
+	entry, err := a.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+	f := &archiveFile{fs: a, entry: entry, name: name}
+	if !entry.info.IsDir() {
+		content, err := entry.content()
+		if err != nil {
+			return nil, &os.PathError{Op: "open", Path: name, Err: err}
+		}
+		f.Reader = bytes.NewReader(content)
+	}
+	return f, nil
+}
+
+func (a *archiveFs) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_APPEND|os.O_CREATE|os.O_TRUNC) != 0 {
+		return nil, &os.PathError{Op: "open", Path: name, Err: syscall.EPERM}
+	}
+	return a.Open(name)
+}
+
+func (a *archiveFs) Remove(name string) error {
+	return &os.PathError{Op: "remove", Path: name, Err: syscall.EPERM}
+}
+
+func (a *archiveFs) RemoveAll(path string) error {
+	return &os.PathError{Op: "remove", Path: path, Err: syscall.EPERM}
+}
+
+func (a *archiveFs) Rename(oldname, newname string) error {
+	return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: syscall.EPERM}
+}
+
+func (a *archiveFs) Stat(name string) (os.FileInfo, error) {
+	entry, err := a.lookup("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return entry.info, nil
+}
+
+func (a *archiveFs) Chmod(name string, mode os.FileMode) error {
+	return &os.PathError{Op: "chmod", Path: name, Err: syscall.EPERM}
+}
+
+func (a *archiveFs) Chtimes(name string, atime time.Time, mtime time.Time) error {
+	return &os.PathError{Op: "chtimes", Path: name, Err: syscall.EPERM}
+}
+
+// archiveFile is an open file of an archiveFs. The content of the files
+// is held in memory, so that they can be read at any offset.
+type archiveFile struct {
+	*bytes.Reader
+	fs     *archiveFs
+	entry  *archiveEntry
+	name   string
+	off    int
+	closed bool
+}
+
+func (f *archiveFile) Close() error {
+	if f.closed {
+		return ErrFileClosed
+	}
+	f.closed = true
+	return nil
+}
+
+func (f *archiveFile) check(op string) error {
+	if f.closed {
+		return ErrFileClosed
+	}
+	if f.Reader == nil {
+		return &os.PathError{Op: op, Path: f.name, Err: syscall.EISDIR}
+	}
+	return nil
+}
+
+func (f *archiveFile) Read(p []byte) (int, error) {
+	if err := f.check("read"); err != nil {
+		return 0, err
+	}
+	return f.Reader.Read(p)
+}
+
+func (f *archiveFile) ReadAt(p []byte, off int64) (int, error) {
+	if err := f.check("read"); err != nil {
+		return 0, err
+	}
+	return f.Reader.ReadAt(p, off)
+}
+
+func (f *archiveFile) Seek(offset int64, whence int) (int64, error) {
+	if err := f.check("seek"); err != nil {
+		return 0, err
+	}
+	return f.Reader.Seek(offset, whence)
+}
+
+func (f *archiveFile) Write(p []byte) (int, error) {
+	return 0, &os.PathError{Op: "write", Path: f.name, Err: syscall.EPERM}
+}
+
+func (f *archiveFile) WriteAt(p []byte, off int64) (int, error) {
+	return 0, &os.PathError{Op: "write", Path: f.name, Err: syscall.EPERM}
+}
+
+func (f *archiveFile) WriteString(s string) (int, error) {
+	return 0, &os.PathError{Op: "write", Path: f.name, Err: syscall.EPERM}
+}
+
+func (f *archiveFile) Name() string { return f.name }
+
+func (f *archiveFile) Readdir(count int) ([]os.FileInfo, error) {
+	if f.closed {
+		return nil, ErrFileClosed
+	}
+	if !f.entry.info.IsDir() {
+		return nil, &os.PathError{Op: "readdir", Path: f.name, Err: syscall.ENOTDIR}
+	}
+	names := f.entry.names[f.off:]
+	if count > 0 {
+		if len(names) == 0 {
+			return nil, io.EOF
+		}
+		if count < len(names) {
+			names = names[:count]
+		}
+	}
+	f.off += len(names)
+
+	dir := archivePath(f.name)
+	fis := make([]os.FileInfo, len(names))
+	for i, name := range names {
+		fis[i] = f.fs.files[path.Join(dir, name)].info
+	}
+	return fis, nil
+}
+
+func (f *archiveFile) Readdirnames(n int) ([]string, error) {
+	fis, err := f.Readdir(n)
+	names := make([]string, len(fis))
+	for i, fi := range fis {
+		names[i] = fi.Name()
+	}
+	return names, err
+}
+
+func (f *archiveFile) Stat() (os.FileInfo, error) {
+	if f.closed {
+		return nil, ErrFileClosed
+	}
+	return f.entry.info, nil
+}
+
+func (f *archiveFile) Sync() error { return nil }
+
+func (f *archiveFile) Truncate(size int64) error {
+	return &os.PathError{Op: "truncate", Path: f.name, Err: syscall.EPERM}
+}
+
+// archiveDirInfo is the os.FileInfo of the directories which are not
+// held by the archive, but hold its files.
+type archiveDirInfo struct {
+	name string
+}
+
+func (fi archiveDirInfo) Name() string       { return fi.name }
+func (fi archiveDirInfo) Size() int64        { return 0 }
+func (fi archiveDirInfo) Mode() os.FileMode  { return os.ModeDir | 0555 }
+func (fi archiveDirInfo) ModTime() time.Time { return time.Time{} }
+func (fi archiveDirInfo) IsDir() bool        { return true }
+func (fi archiveDirInfo) Sys() interface{}   { return nil }
@@ -0,0 +1,197 @@
+package afero
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+)
+
+var archiveFiles = []struct {
+	name, content string
+}{
+	{"a.txt", "hello, world"},
+	{"dir/", ""},
+	{"dir/c.txt", "c"},
+	{"dir/b.txt", "b"},
+	{"implicit/sub/d.txt", "d"},
+}
+
+func newTestZipFs(t *testing.T) Fs {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, file := range archiveFiles {
+		f, err := w.Create(file.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(f, file.content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewZipFs(r)
+}
+
+func newTestTarFs(t *testing.T) Fs {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	w := tar.NewWriter(gw)
+	for _, file := range archiveFiles {
+		hdr := &tar.Header{
+			Name:     file.name,
+			Mode:     0644,
+			Size:     int64(len(file.content)),
+			ModTime:  time.Unix(1500000000, 0),
+			Typeflag: tar.TypeReg,
+		}
+		if file.name[len(file.name)-1] == '/' {
+			hdr.Mode = 0755
+			hdr.Typeflag = tar.TypeDir
+		}
+		if err := w.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(w, file.content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	gr, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs, err := NewTarFs(tar.NewReader(gr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fs
+}
+
+func TestArchiveFs(t *testing.T) {
+	for _, fs := range []Fs{newTestZipFs(t), newTestTarFs(t)} {
+		t.Run(fs.Name(), func(t *testing.T) { testArchiveFs(t, fs) })
+	}
+}
+
+func testArchiveFs(t *testing.T, fs Fs) {
+	f, err := fs.Open("/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 5)
+	if n, err := f.ReadAt(buf, 7); err != nil || string(buf[:n]) != "world" {
+		t.Errorf("ReadAt: got %q, %v", buf[:n], err)
+	}
+	if n, err := f.ReadAt(buf, 10); err != io.EOF || string(buf[:n]) != "ld" {
+		t.Errorf("ReadAt: got %q, %v", buf[:n], err)
+	}
+	if _, err := f.Seek(-5, io.SeekEnd); err != nil {
+		t.Fatal(err)
+	}
+	if content, err := ReadAll(f); err != nil || string(content) != "world" {
+		t.Errorf("Read: got %q, %v", content, err)
+	}
+	if _, err := f.Write([]byte("x")); !errors.Is(err, syscall.EPERM) {
+		t.Errorf("Write: expected EPERM, got %v", err)
+	}
+	f.Close()
+	if _, err := f.Read(buf); err != ErrFileClosed {
+		t.Errorf("Read: expected ErrFileClosed, got %v", err)
+	}
+
+	for name, dir := range map[string]bool{
+		"/":                      true,
+		"a.txt":                  false,
+		"/dir":                   true,
+		"/implicit/sub":          true,
+		"/implicit/sub/d.txt/":   false,
+		"/implicit/../dir/b.txt": false,
+	} {
+		fi, err := fs.Stat(name)
+		if err != nil {
+			t.Errorf("Stat %s: %v", name, err)
+			continue
+		}
+		if fi.IsDir() != dir || fi.Mode().IsDir() != dir {
+			t.Errorf("Stat %s: got IsDir %v, mode %v", name, fi.IsDir(), fi.Mode())
+		}
+	}
+	if _, err := fs.Stat("/missing"); !os.IsNotExist(err) {
+		t.Errorf("Stat: expected a not exist error, got %v", err)
+	}
+
+	d, err := fs.Open("/dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	names, err := d.Readdirnames(1)
+	if err != nil || !reflect.DeepEqual(names, []string{"b.txt"}) {
+		t.Errorf("Readdirnames: got %v, %v", names, err)
+	}
+	names, err = d.Readdirnames(-1)
+	if err != nil || !reflect.DeepEqual(names, []string{"c.txt"}) {
+		t.Errorf("Readdirnames: got %v, %v", names, err)
+	}
+	if _, err := d.Readdirnames(1); err != io.EOF {
+		t.Errorf("Readdirnames: expected io.EOF, got %v", err)
+	}
+	if _, err := d.Read(buf); !errors.Is(err, syscall.EISDIR) {
+		t.Errorf("Read: expected EISDIR, got %v", err)
+	}
+	d.Close()
+
+	fis, err := ReadDir(fs, "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	names = nil
+	for _, fi := range fis {
+		names = append(names, fi.Name())
+	}
+	if !reflect.DeepEqual(names, []string{"a.txt", "dir", "implicit"}) {
+		t.Errorf("ReadDir: got %v", names)
+	}
+
+	if _, err := fs.OpenFile("/a.txt", os.O_RDWR, 0); !errors.Is(err, syscall.EPERM) {
+		t.Errorf("OpenFile: expected EPERM, got %v", err)
+	}
+	if err := fs.Remove("/a.txt"); !errors.Is(err, syscall.EPERM) {
+		t.Errorf("Remove: expected EPERM, got %v", err)
+	}
+
+	// the archive is the base of the union filesystems
+	ufs := NewCopyOnWriteFs(fs, NewMemMapFs())
+	if err := WriteFile(ufs, "/dir/b.txt", []byte("changed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if content, err := ReadFile(ufs, "/dir/b.txt"); err != nil || string(content) != "changed" {
+		t.Errorf("got %q, %v", content, err)
+	}
+	if content, err := ReadFile(fs, "/dir/b.txt"); err != nil || string(content) != "b" {
+		t.Errorf("got %q, %v", content, err)
+	}
+	fis, err = ReadDir(ufs, "/dir")
+	if err != nil || len(fis) != 2 {
+		t.Errorf("got %v, %v", fis, err)
+	}
+}
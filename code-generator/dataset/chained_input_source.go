@@ -0,0 +1,255 @@
+package altsrc
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/urfave/cli.v1"
+)
+
+// SourceFile describes a file loaded into a ChainedInputSource.
+type SourceFile struct {
+	// Path is the path or URL of the file. A path starting with "~/" is
+	// relative to the home directory of the user.
+	Path string
+
+	// Flag names a flag which, when set, overrides Path.
+	Flag string
+
+	// Load creates the InputSourceContext of the file, as do
+	// NewTomlSourceFromFile and NewYamlSourceFromFile.
+	Load func(file string) (InputSourceContext, error)
+
+	// Optional files are skipped when they do not exist.
+	Optional bool
+}
+
+type chainedSource struct {
+	path string
+	isc  InputSourceContext
+}
+
+// ChainedInputSource implements InputSourceContext to return the values
+// of several sources layered over each other, each flag taking its value
+// from the last source which holds it.
+type ChainedInputSource struct {
+	sources []chainedSource
+}
+
+// NewChainedInputSource creates a new ChainedInputSource out of the given
+// files, which are listed in increasing order of precedence, as in
+// "/etc/app.toml", "~/.app.toml" and "./app.toml".
+func NewChainedInputSource(files ...SourceFile) (*ChainedInputSource, error) {
+	return newChainedInputSource(nil, files)
+}
+
+// NewChainedSourceFromFlagFunc creates a new ChainedInputSource from the provided files and source context.
+func NewChainedSourceFromFlagFunc(files ...SourceFile) func(context *cli.Context) (InputSourceContext, error) {
+	return func(context *cli.Context) (InputSourceContext, error) {
+		return newChainedInputSource(context, files)
+	}
+}
+
+func newChainedInputSource(context *cli.Context, files []SourceFile) (*ChainedInputSource, error) {
+	cis := &ChainedInputSource{}
+	for _, file := range files {
+		path := file.Path
+		if file.Flag != "" && context != nil && context.String(file.Flag) != "" {
+			path = context.String(file.Flag)
+		}
+		if path == "" {
+			if file.Optional {
+				continue
+			}
+			if file.Flag == "" {
+				return nil, fmt.Errorf("No path given for a source file")
+			}
+			return nil, fmt.Errorf("No file given for flag '%s'", file.Flag)
+		}
+		path = expandHome(path)
+		if file.Optional && isMissingFile(path) {
+			continue
+		}
+		isc, err := file.Load(path)
+		if err != nil {
+			return nil, err
+		}
+		cis.sources = append(cis.sources, chainedSource{path: path, isc: isc})
+	}
+	return cis, nil
+}
+
+// expandHome replaces a leading "~/" in path with the home directory of
+// the user.
+func expandHome(path string) string {
+	if !strings.HasPrefix(path, "~/") && !strings.HasPrefix(path, `~\`) {
+		return path
+	}
+	home := os.Getenv("HOME")
+	if home == "" {
+		home = os.Getenv("USERPROFILE")
+	}
+	if home == "" {
+		return path
+	}
+	return filepath.Join(home, path[2:])
+}
+
+// isMissingFile reports whether path names a local file which does not
+// exist.
+func isMissingFile(path string) bool {
+	if u, err := url.Parse(path); err == nil && u.Host != "" {
+		return false
+	}
+	_, err := os.Stat(path)
+	return os.IsNotExist(err)
+}
+
+// Files returns the paths of the files loaded, in increasing order of
+// precedence. Optional files which do not exist are not included.
+func (cis *ChainedInputSource) Files() []string {
+	paths := make([]string, len(cis.sources))
+	for i, s := range cis.sources {
+		paths[i] = s.path
+	}
+	return paths
+}
+
+// Origin returns the path of the file supplying the value of the flag
+// with the given name, or the empty string when no file holds a value
+// for it.
+func (cis *ChainedInputSource) Origin(name string) string {
+	if s := cis.lookup(name); s != nil {
+		return s.path
+	}
+	return ""
+}
+
+// lookup returns the source with the highest precedence holding a value
+// for name.
+func (cis *ChainedInputSource) lookup(name string) *chainedSource {
+	for i := len(cis.sources) - 1; i >= 0; i-- {
+		if isSetIn(cis.sources[i].isc, name) {
+			return &cis.sources[i]
+		}
+	}
+	return nil
+}
+
+// isSetIn reports whether isc holds a value for name. Sources other than
+// those of this package are asked for the value as a string, and are
+// taken to hold one when they return a non-empty string or a type error.
+func isSetIn(isc InputSourceContext, name string) bool {
+	if isc, isType := isc.(interface{ isSet(string) bool }); isType {
+		return isc.isSet(name)
+	}
+	value, err := isc.String(name)
+	return value != "" || err != nil
+}
+
+func (cis *ChainedInputSource) isSet(name string) bool {
+	return cis.lookup(name) != nil
+}
+
+// sourceError names the file holding the value which could not be read,
+// unless the error already does.
+func sourceError(s *chainedSource, err error) error {
+	if mis, isType := s.isc.(*MapInputSource); isType && mis.file != "" || err == nil {
+		return err
+	}
+	return fmt.Errorf("%s: %v", s.path, err)
+}
+
+// Int returns an int from the source with the highest precedence holding it, otherwise returns 0
+func (cis *ChainedInputSource) Int(name string) (int, error) {
+	if s := cis.lookup(name); s != nil {
+		value, err := s.isc.Int(name)
+		return value, sourceError(s, err)
+	}
+	return 0, nil
+}
+
+// Duration returns a duration from the source with the highest precedence holding it, otherwise returns 0
+func (cis *ChainedInputSource) Duration(name string) (time.Duration, error) {
+	if s := cis.lookup(name); s != nil {
+		value, err := s.isc.Duration(name)
+		return value, sourceError(s, err)
+	}
+	return 0, nil
+}
+
+// Float64 returns a float64 from the source with the highest precedence holding it, otherwise returns 0
+func (cis *ChainedInputSource) Float64(name string) (float64, error) {
+	if s := cis.lookup(name); s != nil {
+		value, err := s.isc.Float64(name)
+		return value, sourceError(s, err)
+	}
+	return 0, nil
+}
+
+// String returns a string from the source with the highest precedence holding it, otherwise returns an empty string
+func (cis *ChainedInputSource) String(name string) (string, error) {
+	if s := cis.lookup(name); s != nil {
+		value, err := s.isc.String(name)
+		return value, sourceError(s, err)
+	}
+	return "", nil
+}
+
+// StringSlice returns a []string from the source with the highest precedence holding it, otherwise returns nil
+func (cis *ChainedInputSource) StringSlice(name string) ([]string, error) {
+	if s := cis.lookup(name); s != nil {
+		value, err := s.isc.StringSlice(name)
+		return value, sourceError(s, err)
+	}
+	return nil, nil
+}
+
+// IntSlice returns a []int from the source with the highest precedence holding it, otherwise returns nil
+func (cis *ChainedInputSource) IntSlice(name string) ([]int, error) {
+	if s := cis.lookup(name); s != nil {
+		value, err := s.isc.IntSlice(name)
+		return value, sourceError(s, err)
+	}
+	return nil, nil
+}
+
+// Generic returns a cli.Generic from the source with the highest precedence holding it, otherwise returns nil
+func (cis *ChainedInputSource) Generic(name string) (cli.Generic, error) {
+	if s := cis.lookup(name); s != nil {
+		value, err := s.isc.Generic(name)
+		return value, sourceError(s, err)
+	}
+	return nil, nil
+}
+
+// Bool returns a bool from the source with the highest precedence holding it, otherwise returns false
+func (cis *ChainedInputSource) Bool(name string) (bool, error) {
+	if s := cis.lookup(name); s != nil {
+		value, err := s.isc.Bool(name)
+		return value, sourceError(s, err)
+	}
+	return false, nil
+}
+
+// BoolT returns a bool from the source with the highest precedence holding it, otherwise returns true
+func (cis *ChainedInputSource) BoolT(name string) (bool, error) {
+	if s := cis.lookup(name); s != nil {
+		value, err := s.isc.BoolT(name)
+		return value, sourceError(s, err)
+	}
+	return true, nil
+}
+
+// Timestamp returns a time from the source with the highest precedence holding it, otherwise returns the zero time
+func (cis *ChainedInputSource) Timestamp(name, layout string) (time.Time, error) {
+	if s := cis.lookup(name); s != nil {
+		value, err := timestampFrom(s.isc, name, layout)
+		return value, sourceError(s, err)
+	}
+	return time.Time{}, nil
+}
@@ -0,0 +1,147 @@
+package altsrc
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/urfave/cli.v1"
+)
+
+func writeChainedFiles(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "altsrc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(data), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestChainedInputSourcePrecedence(t *testing.T) {
+	dir := writeChainedFiles(t, map[string]string{
+		"system.yaml": "a: 1\nb: 1\ntop:\n  c: system\n",
+		"user.toml":   "b = 2\n[top]\nc = \"user\"\n",
+		"local.json":  `{"top": {"d": 3}}`,
+	})
+	defer os.RemoveAll(dir)
+
+	cis, err := NewChainedInputSource(
+		SourceFile{Path: filepath.Join(dir, "system.yaml"), Load: NewYamlSourceFromFile},
+		SourceFile{Path: filepath.Join(dir, "user.toml"), Load: NewTomlSourceFromFile},
+		SourceFile{Path: filepath.Join(dir, "missing.toml"), Load: NewTomlSourceFromFile, Optional: true},
+		SourceFile{Path: filepath.Join(dir, "local.json"), Load: NewJSONSourceFromFile},
+	)
+	expect(t, err, nil)
+	expect(t, cis.Files(), []string{filepath.Join(dir, "system.yaml"), filepath.Join(dir, "user.toml"), filepath.Join(dir, "local.json")})
+
+	for _, test := range []struct {
+		name   string
+		value  interface{}
+		origin string
+	}{
+		{"a", 1, "system.yaml"},
+		{"b", 2, "user.toml"},
+		{"top.c", "user", "user.toml"},
+		{"top.d", 3, "local.json"},
+		{"e", 0, ""},
+	} {
+		var value interface{}
+		if _, ok := test.value.(string); ok {
+			value, err = cis.String(test.name)
+		} else {
+			value, err = cis.Int(test.name)
+		}
+		expect(t, err, nil)
+		expect(t, value, test.value)
+		origin := cis.Origin(test.name)
+		if origin != "" {
+			origin = filepath.Base(origin)
+		}
+		expect(t, origin, test.origin)
+	}
+
+	b, err := cis.BoolT("e")
+	expect(t, err, nil)
+	expect(t, b, true)
+
+	// Type errors name the file holding the value.
+	_, err = cis.Int("top.c")
+	if err == nil || !strings.Contains(err.Error(), "'"+filepath.Join(dir, "user.toml")+"'") {
+		t.Errorf("expected an error naming user.toml, got %v", err)
+	}
+}
+
+func TestChainedInputSourceMissingFile(t *testing.T) {
+	_, err := NewChainedInputSource(
+		SourceFile{Path: "missing.toml", Load: NewTomlSourceFromFile},
+	)
+	refute(t, err, nil)
+
+	_, err = NewChainedInputSource(SourceFile{Load: NewTomlSourceFromFile})
+	expect(t, err.Error(), "No path given for a source file")
+
+	cis, err := NewChainedInputSource(
+		SourceFile{Path: "missing.toml", Load: NewTomlSourceFromFile, Optional: true},
+		SourceFile{Flag: "config", Load: NewTomlSourceFromFile, Optional: true},
+	)
+	expect(t, err, nil)
+	expect(t, cis.Files(), []string{})
+}
+
+func TestChainedInputSourceHome(t *testing.T) {
+	dir := writeChainedFiles(t, map[string]string{".app.yaml": "test: 15"})
+	defer os.RemoveAll(dir)
+	home := os.Getenv("HOME")
+	os.Setenv("HOME", dir)
+	defer os.Setenv("HOME", home)
+
+	cis, err := NewChainedInputSource(SourceFile{Path: "~/.app.yaml", Load: NewYamlSourceFromFile})
+	expect(t, err, nil)
+	expect(t, cis.Origin("test"), filepath.Join(dir, ".app.yaml"))
+}
+
+func TestCommandChainedFileTest(t *testing.T) {
+	dir := writeChainedFiles(t, map[string]string{
+		"base.yaml":  "test: 15\nother: 1\n",
+		"extra.yaml": "test: 20\n",
+	})
+	defer os.RemoveAll(dir)
+
+	app := cli.NewApp()
+	set := flag.NewFlagSet("test", 0)
+	test := []string{"test-cmd", "--load", filepath.Join(dir, "extra.yaml")}
+	set.Parse(test)
+
+	c := cli.NewContext(app, set, nil)
+
+	command := &cli.Command{
+		Name:        "test-cmd",
+		Aliases:     []string{"tc"},
+		Usage:       "this is for testing",
+		Description: "testing",
+		Action: func(c *cli.Context) error {
+			expect(t, c.Int("test"), 20)
+			expect(t, c.Int("other"), 1)
+			return nil
+		},
+		Flags: []cli.Flag{
+			NewIntFlag(cli.IntFlag{Name: "test"}),
+			NewIntFlag(cli.IntFlag{Name: "other"}),
+			cli.StringFlag{Name: "load"}},
+	}
+	command.Before = InitInputSourceWithContext(command.Flags, NewChainedSourceFromFlagFunc(
+		SourceFile{Path: filepath.Join(dir, "base.yaml"), Load: NewYamlSourceFromFile},
+		SourceFile{Flag: "load", Load: NewYamlSourceFromFile, Optional: true},
+	))
+
+	err := command.Run(c)
+
+	expect(t, err, nil)
+}
@@ -0,0 +1,198 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !plan9
+
+package fsnotify
+
+import (
+	"fmt"
+	"time"
+)
+
+// CoalescedEvent is an event sent by a Coalescer.
+type CoalescedEvent struct {
+	Event
+	// OldName is the name a file renamed to Name had, for a Rename event.
+	OldName string
+}
+
+// String returns a string representation of the event in the form
+// "file: REMOVE|WRITE|...", or "old -> file: RENAME|..." for a renamed file.
+func (e CoalescedEvent) String() string {
+	if e.OldName != "" {
+		return fmt.Sprintf("%q -> %s", e.OldName, e.Event.String())
+	}
+	return e.Event.String()
+}
+
+// Coalescer merges the events of a watcher received for a path within a
+// window of time into a single event, sent once the window is over. A path
+// which is:
+//
+//	created is reported with Create,
+//	renamed to is reported with Rename, along with the name it had,
+//	removed or renamed from is reported with Remove or Rename, unless it
+//	was created in the window, in which case it is not reported at all,
+//	removed and created again is reported with Write,
+//	renamed and renamed back is reported with the Write and Chmod
+//	operations it had,
+//
+// and otherwise with the Write and Chmod operations it had.
+//
+// A Rename event directly followed by a Create event, as the watchers send
+// them for the old and the new name of a file, is taken for the renaming of
+// the file. The events do not tell which file was created, so a file
+// renamed out of the watched paths, followed by the creation of another
+// file, is reported as renamed to that file.
+type Coalescer struct {
+	Events  chan CoalescedEvent
+	window  time.Duration
+	pending map[string]*pendingEvent // Map of the paths being merged (key: path)
+	queue   []*pendingEvent          // The pending events, by deadline
+	renamed string                   // The path of the last event, if a Rename
+}
+
+// pendingEvent is the state of a path within its window.
+type pendingEvent struct {
+	name     string
+	oldName  string    // The name the path was renamed from
+	op       Op        // Write and Chmod operations
+	created  bool      // The path was created in the window
+	removed  Op        // Remove or Rename, if the path is gone
+	replaced bool      // The path was removed and created again
+	deadline time.Time // End of the window
+}
+
+// NewCoalescer coalesces the events received from events, such as the
+// Events channel of a Watcher, within windows of the given duration. The
+// Events channel of the Coalescer is closed, once the pending events are
+// sent, when events is closed.
+func NewCoalescer(events <-chan Event, window time.Duration) *Coalescer {
+	c := &Coalescer{
+		Events:  make(chan CoalescedEvent),
+		window:  window,
+		pending: make(map[string]*pendingEvent),
+	}
+	go c.readEvents(events)
+	return c
+}
+
+func (c *Coalescer) readEvents(events <-chan Event) {
+	defer close(c.Events)
+
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+	defer timer.Stop()
+	for {
+		var timeout <-chan time.Time
+		if len(c.queue) > 0 {
+			timer.Reset(c.queue[0].deadline.Sub(time.Now()))
+			timeout = timer.C
+		}
+		select {
+		case e, ok := <-events:
+			if timeout != nil && !timer.Stop() {
+				<-timer.C
+			}
+			if !ok {
+				c.flush(time.Time{})
+				return
+			}
+			c.add(e, time.Now())
+		case now := <-timeout:
+			c.flush(now)
+		}
+	}
+}
+
+// add merges the event e, received at now, into the pending events.
+func (c *Coalescer) add(e Event, now time.Time) {
+	renamed := c.renamed
+	c.renamed = ""
+
+	p, ok := c.pending[e.Name]
+	if !ok {
+		p = &pendingEvent{name: e.Name, deadline: now.Add(c.window)}
+		c.pending[e.Name] = p
+		c.queue = append(c.queue, p)
+	}
+
+	if e.Op&Create != 0 {
+		if p.removed != 0 {
+			p.removed = 0
+			p.replaced = !p.created
+		} else if !ok {
+			p.created = true
+		}
+		// The file renamed to the path is no longer reported under its old
+		// name, and its changes go with it, unless it was created in the
+		// window.
+		if old := c.pending[renamed]; old != nil && renamed != e.Name {
+			delete(c.pending, renamed)
+			if !old.created {
+				p.oldName = old.oldName
+				if p.oldName == "" {
+					p.oldName = renamed
+				}
+				p.op |= old.op
+				p.created = false
+			}
+			if p.oldName == e.Name {
+				// renamed back, so the file is the one the path had
+				p.oldName = ""
+				p.replaced = false
+			}
+		}
+	}
+	p.op |= e.Op & (Write | Chmod)
+	if e.Op&(Remove|Rename) != 0 {
+		p.removed = e.Op & (Remove | Rename)
+		if p.removed&Rename != 0 {
+			c.renamed = e.Name
+		}
+	}
+}
+
+// flush sends the pending events whose window is over at now, or all of
+// them if now is zero.
+func (c *Coalescer) flush(now time.Time) {
+	for len(c.queue) > 0 && (now.IsZero() || !c.queue[0].deadline.After(now)) {
+		p := c.queue[0]
+		c.queue = c.queue[1:]
+		if c.pending[p.name] != p {
+			// merged into the event of the name it was renamed to
+			continue
+		}
+		delete(c.pending, p.name)
+		if p.name == c.renamed {
+			c.renamed = ""
+		}
+		if e, ok := p.event(); ok {
+			c.Events <- e
+		}
+	}
+}
+
+// event returns the event merged for the path, or false if there is none.
+func (p *pendingEvent) event() (CoalescedEvent, bool) {
+	e := CoalescedEvent{Event: Event{Name: p.name}}
+	switch {
+	case p.removed != 0:
+		if p.created {
+			return e, false
+		}
+		e.Op = p.removed
+	case p.oldName != "":
+		e.Op = Rename | p.op
+		e.OldName = p.oldName
+	case p.created:
+		e.Op = Create
+	case p.replaced:
+		e.Op = Write | p.op
+	default:
+		e.Op = p.op
+	}
+	return e, e.Op != 0
+}
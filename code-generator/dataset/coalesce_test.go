@@ -0,0 +1,160 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build linux
+
+package fsnotify
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// coalesce returns the events sent by a Coalescer for events, received
+// within a window.
+func coalesce(events ...Event) []CoalescedEvent {
+	in := make(chan Event)
+	c := NewCoalescer(in, time.Hour)
+	go func() {
+		for _, e := range events {
+			in <- e
+		}
+		close(in)
+	}()
+	var got []CoalescedEvent
+	for e := range c.Events {
+		got = append(got, e)
+	}
+	return got
+}
+
+func TestCoalescer(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		events []Event
+		want   []CoalescedEvent
+	}{
+		{
+			"create storm",
+			[]Event{{"a", Create}, {"a", Write}, {"a", Chmod}, {"a", Write}},
+			[]CoalescedEvent{{Event: Event{"a", Create}}},
+		},
+		{
+			"writes",
+			[]Event{{"a", Write}, {"b", Chmod}, {"a", Chmod}, {"a", Write}},
+			[]CoalescedEvent{{Event: Event{"a", Write | Chmod}}, {Event: Event{"b", Chmod}}},
+		},
+		{
+			"created and removed",
+			[]Event{{"a", Create}, {"a", Write}, {"a", Remove}},
+			nil,
+		},
+		{
+			"removed and created",
+			[]Event{{"a", Remove}, {"a", Create}, {"a", Chmod}},
+			[]CoalescedEvent{{Event: Event{"a", Write | Chmod}}},
+		},
+		{
+			"written and removed",
+			[]Event{{"a", Write}, {"a", Remove}},
+			[]CoalescedEvent{{Event: Event{"a", Remove}}},
+		},
+		{
+			"rename",
+			[]Event{{"a", Write}, {"a", Rename}, {"b", Create}, {"b", Chmod}},
+			[]CoalescedEvent{{Event: Event{"b", Rename | Write | Chmod}, OldName: "a"}},
+		},
+		{
+			"renames",
+			[]Event{{"a", Rename}, {"b", Create}, {"b", Rename}, {"c", Create}},
+			[]CoalescedEvent{{Event: Event{"c", Rename}, OldName: "a"}},
+		},
+		{
+			"renamed back",
+			[]Event{{"a", Write}, {"a", Rename}, {"b", Create}, {"b", Rename}, {"a", Create}},
+			[]CoalescedEvent{{Event: Event{"a", Write}}},
+		},
+		{
+			"renamed back unchanged",
+			[]Event{{"a", Rename}, {"b", Create}, {"b", Rename}, {"a", Create}},
+			nil,
+		},
+		{
+			"rename away",
+			[]Event{{"a", Rename}, {"b", Write}, {"c", Create}},
+			[]CoalescedEvent{{Event: Event{"a", Rename}}, {Event: Event{"b", Write}}, {Event: Event{"c", Create}}},
+		},
+		{
+			"atomic save",
+			[]Event{{"a~", Create}, {"a~", Write}, {"a~", Rename}, {"a", Create}},
+			[]CoalescedEvent{{Event: Event{"a", Create}}},
+		},
+	} {
+		if got := coalesce(test.events...); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestCoalescerWindow(t *testing.T) {
+	in := make(chan Event)
+	c := NewCoalescer(in, 20*time.Millisecond)
+	defer close(in)
+
+	in <- Event{"a", Write}
+	in <- Event{"a", Write}
+	select {
+	case e := <-c.Events:
+		if want := (CoalescedEvent{Event: Event{"a", Write}}); e != want {
+			t.Fatalf("got %v, want %v", e, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the event")
+	}
+
+	// the window of the next events of the path starts with them
+	in <- Event{"a", Chmod}
+	select {
+	case e := <-c.Events:
+		if want := (CoalescedEvent{Event: Event{"a", Chmod}}); e != want {
+			t.Fatalf("got %v, want %v", e, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the event")
+	}
+}
+
+func TestCoalescerPollingWatcher(t *testing.T) {
+	dir := tempMkdir(t)
+	defer os.RemoveAll(dir)
+	a := filepath.Join(dir, "a")
+	b := filepath.Join(dir, "b")
+	createFile(t, a, "a")
+
+	w := newPollingWatcher(t)
+	if err := w.Add(dir); err != nil {
+		t.Fatalf("Add() failed: %s", err)
+	}
+	c := NewCoalescer(w.Events, time.Hour)
+
+	appendFile(t, a, "a")
+	time.Sleep(3 * testPollInterval)
+	if err := os.Rename(a, b); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(3 * testPollInterval)
+	w.Close()
+
+	var got []CoalescedEvent
+	for e := range c.Events {
+		got = append(got, e)
+	}
+	want := []CoalescedEvent{{Event: Event{b, Rename | Write}, OldName: a}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
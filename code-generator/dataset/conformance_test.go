@@ -0,0 +1,89 @@
+package afero_test
+
+import (
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/afero/aferotest"
+)
+
+func TestConformance(t *testing.T) {
+	osDir, err := afero.TempDir(afero.NewOsFs(), "", "afero")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(osDir)
+
+	for _, fs := range []afero.Fs{
+		afero.NewBasePathFs(afero.NewOsFs(), osDir),
+		afero.NewMemMapFs(),
+		afero.NewBasePathFs(afero.NewMemMapFs(), "/base"),
+		afero.NewCopyOnWriteFs(afero.NewReadOnlyFs(afero.NewMemMapFs()), afero.NewMemMapFs()),
+		afero.NewOverlayFs(afero.NewReadOnlyFs(afero.NewMemMapFs()), afero.NewMemMapFs()),
+		afero.NewQuotaFs(afero.NewMemMapFs(), 1<<20),
+		afero.NewFaultFs(afero.NewMemMapFs()),
+		afero.NewSlowFs(afero.NewMemMapFs(), 0),
+	} {
+		t.Run(fs.Name(), func(t *testing.T) { aferotest.TestFs(t, fs) })
+	}
+}
+
+// The filtering Fs are checked on the files they let through.
+func TestFilterFsConformance(t *testing.T) {
+	base := afero.NewMemMapFs()
+	for _, name := range []string{"/dir/a.txt", "/dir/b.txt", "/dir/c.go"} {
+		afero.WriteFile(base, name, []byte("base"), 0644)
+	}
+	aferotest.TestReadOnlyFs(t, afero.NewReadOnlyFs(base), "/dir", []string{"a.txt", "b.txt", "c.go"})
+
+	fs := afero.NewRegexpFs(base, regexp.MustCompile(`\.txt$`))
+	aferotest.TestDir(t, fs, "/dir", []string{"a.txt", "b.txt"})
+	if _, err := fs.Stat("/dir/c.go"); !os.IsNotExist(err) {
+		t.Errorf("Stat: expected the filtered file to be missing, got %v", err)
+	}
+	f, err := fs.Create("/dir/d.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	aferotest.TestFile(t, f)
+}
+
+func TestUnionFsConformance(t *testing.T) {
+	for _, union := range []func(base, layer afero.Fs) afero.Fs{
+		afero.NewCopyOnWriteFs,
+		afero.NewOverlayFs,
+	} {
+		base := afero.NewMemMapFs()
+		fs := union(afero.NewReadOnlyFs(base), afero.NewMemMapFs())
+		t.Run(fs.Name(), func(t *testing.T) { aferotest.TestUnionFs(t, fs, base) })
+	}
+}
+
+func TestUnionFileConformance(t *testing.T) {
+	base := afero.NewMemMapFs()
+	layer := afero.NewMemMapFs()
+	for _, name := range []string{"/dir/e", "/dir/b", "/dir/d"} {
+		afero.WriteFile(base, name, []byte("base"), 0644)
+	}
+	for _, name := range []string{"/dir/c", "/dir/a", "/dir/b"} {
+		afero.WriteFile(layer, name, []byte("layer"), 0644)
+	}
+	for _, fs := range []afero.Fs{
+		afero.NewCopyOnWriteFs(base, layer),
+		afero.NewOverlayFs(base, layer),
+	} {
+		aferotest.TestDir(t, fs, "/dir", []string{"a", "b", "c", "d", "e"})
+	}
+
+	bfile, err := base.Create("/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lfile, err := layer.Create("/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	aferotest.TestFile(t, afero.NewUnionFile(bfile, lfile))
+}
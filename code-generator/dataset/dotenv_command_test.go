@@ -0,0 +1,233 @@
+package altsrc
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/urfave/cli.v1"
+)
+
+func TestCommandDotenvFileTest(t *testing.T) {
+	app := cli.NewApp()
+	set := flag.NewFlagSet("test", 0)
+	ioutil.WriteFile("current.env", []byte("test=15"), 0666)
+	defer os.Remove("current.env")
+
+	test := []string{"test-cmd", "--load", "current.env"}
+	set.Parse(test)
+
+	c := cli.NewContext(app, set, nil)
+
+	command := &cli.Command{
+		Name:        "test-cmd",
+		Aliases:     []string{"tc"},
+		Usage:       "this is for testing",
+		Description: "testing",
+		Action: func(c *cli.Context) error {
+			val := c.Int("test")
+			expect(t, val, 15)
+			return nil
+		},
+		Flags: []cli.Flag{
+			NewIntFlag(cli.IntFlag{Name: "test"}),
+			cli.StringFlag{Name: "load"}},
+	}
+	command.Before = InitInputSourceWithContext(command.Flags, NewDotenvSourceFromFlagFunc("load"))
+
+	err := command.Run(c)
+
+	expect(t, err, nil)
+}
+
+func TestCommandDotenvFileTestGlobalEnvVarWinsNested(t *testing.T) {
+	app := cli.NewApp()
+	set := flag.NewFlagSet("test", 0)
+	ioutil.WriteFile("current.env", []byte("top.test=15"), 0666)
+	defer os.Remove("current.env")
+
+	os.Setenv("THE_TEST", "10")
+	defer os.Setenv("THE_TEST", "")
+	test := []string{"test-cmd", "--load", "current.env"}
+	set.Parse(test)
+
+	c := cli.NewContext(app, set, nil)
+
+	command := &cli.Command{
+		Name:        "test-cmd",
+		Aliases:     []string{"tc"},
+		Usage:       "this is for testing",
+		Description: "testing",
+		Action: func(c *cli.Context) error {
+			val := c.Int("top.test")
+			expect(t, val, 10)
+			return nil
+		},
+		Flags: []cli.Flag{
+			NewIntFlag(cli.IntFlag{Name: "top.test", EnvVar: "THE_TEST"}),
+			cli.StringFlag{Name: "load"}},
+	}
+	command.Before = InitInputSourceWithContext(command.Flags, NewDotenvSourceFromFlagFunc("load"))
+
+	err := command.Run(c)
+
+	expect(t, err, nil)
+}
+
+func TestCommandDotenvFileTestSpecifiedFlagWinsNested(t *testing.T) {
+	app := cli.NewApp()
+	set := flag.NewFlagSet("test", 0)
+	ioutil.WriteFile("current.env", []byte("top.test=15"), 0666)
+	defer os.Remove("current.env")
+
+	test := []string{"test-cmd", "--load", "current.env", "--top.test", "7"}
+	set.Parse(test)
+
+	c := cli.NewContext(app, set, nil)
+
+	command := &cli.Command{
+		Name:        "test-cmd",
+		Aliases:     []string{"tc"},
+		Usage:       "this is for testing",
+		Description: "testing",
+		Action: func(c *cli.Context) error {
+			val := c.Int("top.test")
+			expect(t, val, 7)
+			return nil
+		},
+		Flags: []cli.Flag{
+			NewIntFlag(cli.IntFlag{Name: "top.test"}),
+			cli.StringFlag{Name: "load"}},
+	}
+	command.Before = InitInputSourceWithContext(command.Flags, NewDotenvSourceFromFlagFunc("load"))
+
+	err := command.Run(c)
+
+	expect(t, err, nil)
+}
+
+func TestCommandDotenvFileTestDefaultValueFileWinsNested(t *testing.T) {
+	app := cli.NewApp()
+	set := flag.NewFlagSet("test", 0)
+	ioutil.WriteFile("current.env", []byte("top.test=15"), 0666)
+	defer os.Remove("current.env")
+
+	test := []string{"test-cmd", "--load", "current.env"}
+	set.Parse(test)
+
+	c := cli.NewContext(app, set, nil)
+
+	command := &cli.Command{
+		Name:        "test-cmd",
+		Aliases:     []string{"tc"},
+		Usage:       "this is for testing",
+		Description: "testing",
+		Action: func(c *cli.Context) error {
+			val := c.Int("top.test")
+			expect(t, val, 15)
+			return nil
+		},
+		Flags: []cli.Flag{
+			NewIntFlag(cli.IntFlag{Name: "top.test", Value: 7}),
+			cli.StringFlag{Name: "load"}},
+	}
+	command.Before = InitInputSourceWithContext(command.Flags, NewDotenvSourceFromFlagFunc("load"))
+
+	err := command.Run(c)
+
+	expect(t, err, nil)
+}
+
+func TestDotenvSourceValues(t *testing.T) {
+	ioutil.WriteFile("current.env", []byte(`# settings
+export NAME=web
+PORT=8080 # the port
+RATE=0.5
+DEBUG=true
+QUOTED="8080"
+ESCAPED="a\n\"b\" # c" # comment
+RAW='a\n'
+top.test=15
+`), 0666)
+	defer os.Remove("current.env")
+
+	isc, err := NewDotenvSourceFromFile("current.env")
+	expect(t, err, nil)
+	s, err := isc.String("NAME")
+	expect(t, err, nil)
+	expect(t, s, "web")
+	i, err := isc.Int("PORT")
+	expect(t, err, nil)
+	expect(t, i, 8080)
+	f, err := isc.Float64("RATE")
+	expect(t, err, nil)
+	expect(t, f, 0.5)
+	b, err := isc.Bool("DEBUG")
+	expect(t, err, nil)
+	expect(t, b, true)
+	s, err = isc.String("QUOTED")
+	expect(t, err, nil)
+	expect(t, s, "8080")
+	i, err = isc.Int("QUOTED")
+	expect(t, err, nil)
+	expect(t, i, 8080)
+	s, err = isc.String("ESCAPED")
+	expect(t, err, nil)
+	expect(t, s, "a\n\"b\" # c")
+	s, err = isc.String("RAW")
+	expect(t, err, nil)
+	expect(t, s, `a\n`)
+	i, err = isc.Int("top.test")
+	expect(t, err, nil)
+	expect(t, i, 15)
+
+	// The values are kept as they are written, for the string flags.
+	s, err = isc.String("PORT")
+	expect(t, err, nil)
+	expect(t, s, "8080")
+	s, err = isc.String("RATE")
+	expect(t, err, nil)
+	expect(t, s, "0.5")
+}
+
+func TestDotenvSourceStrings(t *testing.T) {
+	ioutil.WriteFile("current.env", []byte("VERSION=1.10\nCODE=007\nTIMEOUT=1m30s\nNAME=web\n"), 0666)
+	defer os.Remove("current.env")
+
+	isc, err := NewDotenvSourceFromFile("current.env")
+	expect(t, err, nil)
+	s, err := isc.String("VERSION")
+	expect(t, err, nil)
+	expect(t, s, "1.10")
+	s, err = isc.String("CODE")
+	expect(t, err, nil)
+	expect(t, s, "007")
+	i, err := isc.Int("CODE")
+	expect(t, err, nil)
+	expect(t, i, 7)
+	d, err := isc.Duration("TIMEOUT")
+	expect(t, err, nil)
+	expect(t, d, 90*time.Second)
+	_, err = isc.Int("NAME")
+	expect(t, err.Error(), "Mismatched type for key 'NAME' in 'current.env'. Expected an integer but actual is string \"web\"")
+}
+
+func TestDotenvSourceErrors(t *testing.T) {
+	defer os.Remove("current.env")
+	for data, msg := range map[string]string{
+		"A=1\nB\n":     "line 2: expected KEY=value",
+		"A=\"x\n":      "line 1: unterminated quoted value \"x",
+		"A='x' y\n":    "line 1: unexpected \"y\" after quoted value",
+		"A=1\nA.B=2\n": "line 2: key A is set to a value, not to a set of keys",
+		"A.B=1\nA=2\n": "line 2: key A is a set of keys, not a value",
+	} {
+		ioutil.WriteFile("current.env", []byte(data), 0666)
+		_, err := NewDotenvSourceFromFile("current.env")
+		if err == nil || !strings.Contains(err.Error(), msg) {
+			t.Errorf("loading %q: expected error %q, got %v", data, msg, err)
+		}
+	}
+}
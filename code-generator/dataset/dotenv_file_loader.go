@@ -0,0 +1,135 @@
+package altsrc
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+
+	"gopkg.in/urfave/cli.v1"
+)
+
+type dotenvSourceContext struct {
+	FilePath string
+}
+
+// NewDotenvSourceFromFile creates a new dotenv InputSourceContext from a
+// filepath. The file holds one KEY=value assignment per line, optionally
+// preceded by "export", and lines starting with '#' are comments. Keys
+// holding '.' delimiters are nested, as in the other sources.
+//
+// Values are loaded as the strings they are written as, and converted
+// when they are read by the int, float64, bool and duration flags. Double
+// quoted values may hold the escape sequences \n, \r, \t, \" and \\.
+func NewDotenvSourceFromFile(file string) (InputSourceContext, error) {
+	return newDotenvSource(defaultRemoteLoader, file)
+}
+
+func newDotenvSource(loader *RemoteLoader, file string) (InputSourceContext, error) {
+	dsc := &dotenvSourceContext{FilePath: file}
+	results, err := readCommandDotenv(loader, dsc.FilePath)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to load dotenv file '%s': inner error: \n'%v'", dsc.FilePath, err.Error())
+	}
+	mis := newMapInputSource(dsc.FilePath, results)
+	mis.text = true
+	return mis, nil
+}
+
+// NewDotenvSourceFromFlagFunc creates a new dotenv InputSourceContext from a provided flag name and source context.
+func NewDotenvSourceFromFlagFunc(flagFileName string) func(context *cli.Context) (InputSourceContext, error) {
+	return func(context *cli.Context) (InputSourceContext, error) {
+		filePath := context.String(flagFileName)
+		return NewDotenvSourceFromFile(filePath)
+	}
+}
+
+func readCommandDotenv(loader *RemoteLoader, filePath string) (map[interface{}]interface{}, error) {
+	b, err := loadDataFrom(loader, filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make(map[interface{}]interface{})
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		if strings.HasPrefix(text, "export ") {
+			text = strings.TrimSpace(text[len("export "):])
+		}
+		i := strings.Index(text, "=")
+		if i < 1 {
+			return nil, fmt.Errorf("line %d: expected KEY=value", line)
+		}
+		key := strings.TrimSpace(text[:i])
+		val, err := dotenvValue(strings.TrimSpace(text[i+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		if err := setNested(ret, key, val); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func dotenvValue(s string) (string, error) {
+	if s == "" {
+		return "", nil
+	}
+	switch quote := s[0]; quote {
+	case '\'', '"':
+		end := 1
+		for ; end < len(s) && s[end] != quote; end++ {
+			if quote == '"' && s[end] == '\\' {
+				end++
+			}
+		}
+		if end >= len(s) {
+			return "", fmt.Errorf("unterminated quoted value %s", s)
+		}
+		if rest := strings.TrimSpace(s[end+1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+			return "", fmt.Errorf("unexpected %q after quoted value", rest)
+		}
+		if quote == '\'' {
+			return s[1:end], nil
+		}
+		return strings.NewReplacer(`\n`, "\n", `\r`, "\r", `\t`, "\t", `\"`, `"`, `\\`, `\`).Replace(s[1:end]), nil
+	}
+	// Comments following unquoted values must be set apart by a space.
+	if i := strings.Index(s, " #"); i != -1 {
+		s = strings.TrimSpace(s[:i])
+	}
+	return s, nil
+}
+
+// setNested sets the value of the '.' delimited key in tree, creating the
+// maps holding it as needed.
+func setNested(tree map[interface{}]interface{}, key string, val interface{}) error {
+	sections := strings.Split(key, ".")
+	node := tree
+	for i, section := range sections[:len(sections)-1] {
+		child, ok := node[section]
+		if !ok {
+			child = make(map[interface{}]interface{})
+			node[section] = child
+		}
+		ctype, ok := child.(map[interface{}]interface{})
+		if !ok {
+			return fmt.Errorf("key %s is set to a value, not to a set of keys", strings.Join(sections[:i+1], "."))
+		}
+		node = ctype
+	}
+	last := sections[len(sections)-1]
+	if _, ok := node[last].(map[interface{}]interface{}); ok {
+		return fmt.Errorf("key %s is a set of keys, not a value", key)
+	}
+	node[last] = val
+	return nil
+}
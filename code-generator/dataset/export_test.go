@@ -0,0 +1,6 @@
+package afero
+
+// NewUnionFile lets the external tests check the UnionFile.
+func NewUnionFile(base, layer File) File {
+	return &UnionFile{base: base, layer: layer}
+}
@@ -0,0 +1,290 @@
+package afero
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// A FaultRule makes the FaultFs fail the operations it matches.
+type FaultRule struct {
+	// Op is the name of the method of the Fs or of the File, such as
+	// "OpenFile", "Write" or "Sync". An empty Op matches every method.
+	Op string
+	// Path is the pattern, as in filepath.Match, the name of the file must
+	// match. An empty Path matches every file.
+	Path string
+	// Nth fails only the Nth call matched by the rule, counting from 1,
+	// instead of all of them.
+	Nth int
+	// Err is the error the operation fails with, syscall.EIO if nil.
+	Err error
+}
+
+type faultRule struct {
+	FaultRule
+	calls int
+}
+
+func (r *faultRule) match(op, name string) bool {
+	if r.Op != "" && r.Op != op {
+		return false
+	}
+	if r.Path != "" {
+		if ok, _ := filepath.Match(r.Path, name); !ok {
+			return false
+		}
+	}
+	r.calls++
+	return r.Nth == 0 || r.Nth == r.calls
+}
+
+// The FaultFs is a wrapper which makes the operations of the source Fs,
+// and of the files it opens, fail as its rules say, so that the failure
+// paths of the code using them can be tested deterministically. The
+// failing operations are not passed to the source.
+type FaultFs struct {
+	hookFs
+	mu    sync.Mutex
+	rules []*faultRule
+}
+
+func NewFaultFs(source Fs, rules ...FaultRule) *FaultFs {
+	f := &FaultFs{}
+	f.hookFs = hookFs{source: source, name: "FaultFs", hook: f.fault}
+	for _, rule := range rules {
+		f.AddRule(rule)
+	}
+	return f
+}
+
+// AddRule adds a rule to the rules of the FaultFs. An operation fails
+// with the error of the first rule it matches.
+func (f *FaultFs) AddRule(rule FaultRule) {
+	f.mu.Lock()
+	f.rules = append(f.rules, &faultRule{FaultRule: rule})
+	f.mu.Unlock()
+}
+
+func (f *FaultFs) fault(op, name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, rule := range f.rules {
+		if rule.match(op, name) {
+			if rule.Err != nil {
+				return rule.Err
+			}
+			return syscall.EIO
+		}
+	}
+	return nil
+}
+
+// The SlowFs is a wrapper which delays every operation of the source Fs,
+// and of the files it opens, by its latency.
+type SlowFs struct {
+	hookFs
+	latency time.Duration
+}
+
+func NewSlowFs(source Fs, latency time.Duration) *SlowFs {
+	s := &SlowFs{latency: latency}
+	s.hookFs = hookFs{source: source, name: "SlowFs", hook: s.sleep}
+	return s
+}
+
+func (s *SlowFs) sleep(op, name string) error {
+	time.Sleep(s.latency)
+	return nil
+}
+
+// hookFs calls its hook before each operation of the source Fs and of
+// the files it opens, and fails the operation with the error of the hook.
+type hookFs struct {
+	source Fs
+	name   string
+	hook   func(op, name string) error
+}
+
+func (h *hookFs) Name() string { return h.name }
+
+func (h *hookFs) Create(name string) (File, error) {
+	if err := h.hook("Create", name); err != nil {
+		return nil, &os.PathError{Op: "create", Path: name, Err: err}
+	}
+	f, err := h.source.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	return &hookFile{File: f, name: name, hook: h.hook}, nil
+}
+
+func (h *hookFs) Mkdir(name string, perm os.FileMode) error {
+	if err := h.hook("Mkdir", name); err != nil {
+		return &os.PathError{Op: "mkdir", Path: name, Err: err}
+	}
+	return h.source.Mkdir(name, perm)
+}
+
+func (h *hookFs) MkdirAll(path string, perm os.FileMode) error {
+	if err := h.hook("MkdirAll", path); err != nil {
+		return &os.PathError{Op: "mkdir", Path: path, Err: err}
+	}
+	return h.source.MkdirAll(path, perm)
+}
+
+func (h *hookFs) Open(name string) (File, error) {
+	if err := h.hook("Open", name); err != nil {
+		return nil, &os.PathError{Op: "open", Path: name, Err: err}
+	}
+	f, err := h.source.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &hookFile{File: f, name: name, hook: h.hook}, nil
+}
+
+func (h *hookFs) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	if err := h.hook("OpenFile", name); err != nil {
+		return nil, &os.PathError{Op: "open", Path: name, Err: err}
+	}
+	f, err := h.source.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	return &hookFile{File: f, name: name, hook: h.hook}, nil
+}
+
+func (h *hookFs) Remove(name string) error {
+	if err := h.hook("Remove", name); err != nil {
+		return &os.PathError{Op: "remove", Path: name, Err: err}
+	}
+	return h.source.Remove(name)
+}
+
+func (h *hookFs) RemoveAll(path string) error {
+	if err := h.hook("RemoveAll", path); err != nil {
+		return &os.PathError{Op: "remove", Path: path, Err: err}
+	}
+	return h.source.RemoveAll(path)
+}
+
+func (h *hookFs) Rename(oldname, newname string) error {
+	if err := h.hook("Rename", oldname); err != nil {
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: err}
+	}
+	return h.source.Rename(oldname, newname)
+}
+
+func (h *hookFs) Stat(name string) (os.FileInfo, error) {
+	if err := h.hook("Stat", name); err != nil {
+		return nil, &os.PathError{Op: "stat", Path: name, Err: err}
+	}
+	return h.source.Stat(name)
+}
+
+func (h *hookFs) Chmod(name string, mode os.FileMode) error {
+	if err := h.hook("Chmod", name); err != nil {
+		return &os.PathError{Op: "chmod", Path: name, Err: err}
+	}
+	return h.source.Chmod(name, mode)
+}
+
+func (h *hookFs) Chtimes(name string, atime time.Time, mtime time.Time) error {
+	if err := h.hook("Chtimes", name); err != nil {
+		return &os.PathError{Op: "chtimes", Path: name, Err: err}
+	}
+	return h.source.Chtimes(name, atime, mtime)
+}
+
+type hookFile struct {
+	File
+	name string
+	hook func(op, name string) error
+}
+
+func (f *hookFile) Close() error {
+	if err := f.hook("Close", f.name); err != nil {
+		return &os.PathError{Op: "close", Path: f.name, Err: err}
+	}
+	return f.File.Close()
+}
+
+func (f *hookFile) Read(p []byte) (int, error) {
+	if err := f.hook("Read", f.name); err != nil {
+		return 0, &os.PathError{Op: "read", Path: f.name, Err: err}
+	}
+	return f.File.Read(p)
+}
+
+func (f *hookFile) ReadAt(p []byte, off int64) (int, error) {
+	if err := f.hook("ReadAt", f.name); err != nil {
+		return 0, &os.PathError{Op: "read", Path: f.name, Err: err}
+	}
+	return f.File.ReadAt(p, off)
+}
+
+func (f *hookFile) Seek(offset int64, whence int) (int64, error) {
+	if err := f.hook("Seek", f.name); err != nil {
+		return 0, &os.PathError{Op: "seek", Path: f.name, Err: err}
+	}
+	return f.File.Seek(offset, whence)
+}
+
+func (f *hookFile) Write(p []byte) (int, error) {
+	if err := f.hook("Write", f.name); err != nil {
+		return 0, &os.PathError{Op: "write", Path: f.name, Err: err}
+	}
+	return f.File.Write(p)
+}
+
+func (f *hookFile) WriteAt(p []byte, off int64) (int, error) {
+	if err := f.hook("WriteAt", f.name); err != nil {
+		return 0, &os.PathError{Op: "write", Path: f.name, Err: err}
+	}
+	return f.File.WriteAt(p, off)
+}
+
+func (f *hookFile) WriteString(s string) (int, error) {
+	if err := f.hook("WriteString", f.name); err != nil {
+		return 0, &os.PathError{Op: "write", Path: f.name, Err: err}
+	}
+	return f.File.WriteString(s)
+}
+
+func (f *hookFile) Readdir(count int) ([]os.FileInfo, error) {
+	if err := f.hook("Readdir", f.name); err != nil {
+		return nil, &os.PathError{Op: "readdir", Path: f.name, Err: err}
+	}
+	return f.File.Readdir(count)
+}
+
+func (f *hookFile) Readdirnames(n int) ([]string, error) {
+	if err := f.hook("Readdirnames", f.name); err != nil {
+		return nil, &os.PathError{Op: "readdir", Path: f.name, Err: err}
+	}
+	return f.File.Readdirnames(n)
+}
+
+func (f *hookFile) Stat() (os.FileInfo, error) {
+	if err := f.hook("Stat", f.name); err != nil {
+		return nil, &os.PathError{Op: "stat", Path: f.name, Err: err}
+	}
+	return f.File.Stat()
+}
+
+func (f *hookFile) Sync() error {
+	if err := f.hook("Sync", f.name); err != nil {
+		return &os.PathError{Op: "sync", Path: f.name, Err: err}
+	}
+	return f.File.Sync()
+}
+
+func (f *hookFile) Truncate(size int64) error {
+	if err := f.hook("Truncate", f.name); err != nil {
+		return &os.PathError{Op: "truncate", Path: f.name, Err: err}
+	}
+	return f.File.Truncate(size)
+}
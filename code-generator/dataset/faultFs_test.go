@@ -0,0 +1,81 @@
+package afero
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestFaultFs(t *testing.T) {
+	ffs := NewFaultFs(NewMemMapFs(),
+		FaultRule{Op: "Write", Path: "/data/*", Nth: 2},
+		FaultRule{Op: "Sync"},
+		FaultRule{Op: "Open", Path: "/secret", Err: os.ErrPermission},
+	)
+
+	f, err := ffs.Create("/data/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("one")); err != nil {
+		t.Errorf("first write: %v", err)
+	}
+	if _, err := f.Write([]byte("two")); !errors.Is(err, syscall.EIO) {
+		t.Errorf("second write: expected EIO, got %v", err)
+	}
+	if _, err := f.Write([]byte("three")); err != nil {
+		t.Errorf("third write: %v", err)
+	}
+	if err := f.Sync(); !errors.Is(err, syscall.EIO) {
+		t.Errorf("sync: expected EIO, got %v", err)
+	}
+	f.Close()
+	if content, err := ReadFile(ffs, "/data/file"); err != nil || string(content) != "onethree" {
+		t.Errorf("got %q, %v", content, err)
+	}
+
+	WriteFile(ffs, "/secret", []byte("secret"), 0600)
+	if _, err := ffs.Open("/secret"); !os.IsPermission(err) {
+		t.Errorf("expected a permission error, got %v", err)
+	}
+	if _, err := ffs.OpenFile("/secret", os.O_RDONLY, 0); err != nil {
+		t.Errorf("OpenFile: %v", err)
+	}
+
+	ffs.AddRule(FaultRule{Op: "Remove"})
+	if err := ffs.Remove("/secret"); !errors.Is(err, syscall.EIO) {
+		t.Errorf("expected EIO, got %v", err)
+	}
+	if exists, _ := Exists(ffs, "/secret"); !exists {
+		t.Error("the failed remove reached the source")
+	}
+}
+
+func TestFaultFsCopyOnWrite(t *testing.T) {
+	base := NewMemMapFs()
+	layer := NewMemMapFs()
+	WriteFile(base, "/data/file", []byte("content"), 0644)
+
+	// the copy to the layer fails half-way, and leaves nothing behind
+	ufs := NewCopyOnWriteFs(base, NewFaultFs(layer, FaultRule{Op: "Write", Path: "/data/file"}))
+	if err := ufs.Chmod("/data/file", 0600); !errors.Is(err, syscall.EIO) {
+		t.Errorf("expected EIO, got %v", err)
+	}
+	if exists, _ := Exists(layer, "/data/file"); exists {
+		t.Error("the failed copy was left in the layer")
+	}
+}
+
+func TestSlowFs(t *testing.T) {
+	sfs := NewSlowFs(NewMemMapFs(), 10*time.Millisecond)
+	start := time.Now()
+	// Create, Write and Close
+	if err := WriteFile(sfs, "/file", []byte("content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("expected 3 delayed operations, took %v", elapsed)
+	}
+}
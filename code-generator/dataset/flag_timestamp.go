@@ -0,0 +1,114 @@
+package altsrc
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"gopkg.in/urfave/cli.v1"
+)
+
+// Timestamp is a cli.Generic holding a time given in a layout, as
+// understood by time.Parse.
+type Timestamp struct {
+	layout string
+	time   time.Time
+	isSet  bool
+}
+
+// NewTimestamp creates a new Timestamp in the given layout, holding value.
+func NewTimestamp(layout string, value time.Time) *Timestamp {
+	return &Timestamp{layout: layout, time: value}
+}
+
+// Set parses value in the layout of the Timestamp.
+func (t *Timestamp) Set(value string) error {
+	parsed, err := time.Parse(t.layout, value)
+	if err != nil {
+		return err
+	}
+	t.time = parsed
+	t.isSet = true
+	return nil
+}
+
+// String returns the time in the layout of the Timestamp.
+func (t *Timestamp) String() string {
+	if t.time.IsZero() && !t.isSet {
+		return ""
+	}
+	return t.time.Format(t.layout)
+}
+
+// Value returns the time held.
+func (t *Timestamp) Value() time.Time {
+	return t.time
+}
+
+// TimestampFlag is the flag type that wraps a cli.GenericFlag holding a
+// *Timestamp to allow for other values to be specified
+type TimestampFlag struct {
+	cli.GenericFlag
+	set *flag.FlagSet
+}
+
+// NewTimestampFlag creates a new TimestampFlag. The Value of fl must be a
+// *Timestamp.
+func NewTimestampFlag(fl cli.GenericFlag) *TimestampFlag {
+	return &TimestampFlag{GenericFlag: fl, set: nil}
+}
+
+// Apply saves the flagSet for later usage calls, then calls the
+// wrapped GenericFlag.Apply
+func (f *TimestampFlag) Apply(set *flag.FlagSet) {
+	f.set = set
+	f.GenericFlag.Apply(set)
+}
+
+// ApplyWithError saves the flagSet for later usage calls, then calls the
+// wrapped GenericFlag.ApplyWithError
+func (f *TimestampFlag) ApplyWithError(set *flag.FlagSet) error {
+	f.set = set
+	return f.GenericFlag.ApplyWithError(set)
+}
+
+// ApplyInputSourceValue applies a Timestamp value to the flagSet if required
+func (f *TimestampFlag) ApplyInputSourceValue(context *cli.Context, isc InputSourceContext) error {
+	if f.set != nil {
+		if !(context.IsSet(f.Name) || isEnvVarSet(f.EnvVar)) {
+			ts, isType := f.Value.(*Timestamp)
+			if !isType {
+				return fmt.Errorf("Value of timestamp flag '%s' is not a *Timestamp", f.Name)
+			}
+			value, err := timestampFrom(isc, f.GenericFlag.Name, ts.layout)
+			if err != nil {
+				return err
+			}
+			if !value.IsZero() {
+				eachName(f.Name, func(name string) {
+					if underlyingFlag := f.set.Lookup(name); underlyingFlag != nil {
+						if ts, isType := underlyingFlag.Value.(*Timestamp); isType {
+							ts.time, ts.isSet = value, true
+						}
+					}
+				})
+			}
+		}
+	}
+	return nil
+}
+
+// timestampFrom reads a timestamp from isc, which is read as a string in
+// the given layout unless isc supports timestamps.
+func timestampFrom(isc InputSourceContext, name, layout string) (time.Time, error) {
+	if tsc, isType := isc.(interface {
+		Timestamp(name, layout string) (time.Time, error)
+	}); isType {
+		return tsc.Timestamp(name, layout)
+	}
+	value, err := isc.String(name)
+	if err != nil || value == "" {
+		return time.Time{}, err
+	}
+	return time.Parse(layout, value)
+}
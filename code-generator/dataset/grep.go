@@ -0,0 +1,146 @@
+// Copyright ©2015 Steve Francia <spf@spf13.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package afero
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"os"
+)
+
+// A Matcher finds the matches within a line, as a *regexp.Regexp does:
+// FindAllIndex returns the start and end offsets of at most n successive
+// matches of line, or of all of them if n is negative.
+type Matcher interface {
+	FindAllIndex(line []byte, n int) [][]int
+}
+
+// BytesMatcher returns a Matcher which matches any of the subslices,
+// byte for byte. Where several subslices match at the same offset, the
+// longest one is matched.
+func BytesMatcher(subslices ...[]byte) Matcher {
+	return bytesMatcher(subslices)
+}
+
+type bytesMatcher [][]byte
+
+func (m bytesMatcher) FindAllIndex(line []byte, n int) [][]int {
+	var matches [][]int
+	for off := 0; off < len(line) && (n < 0 || len(matches) < n); {
+		start, end := -1, -1
+		for _, sl := range m {
+			if len(sl) == 0 {
+				continue
+			}
+			i := bytes.Index(line[off:], sl)
+			if i < 0 {
+				continue
+			}
+			if i += off; start < 0 || i < start || i == start && i+len(sl) > end {
+				start, end = i, i+len(sl)
+			}
+		}
+		if start < 0 {
+			break
+		}
+		matches = append(matches, []int{start, end})
+		off = end
+	}
+	return matches
+}
+
+// A GrepMatch is a match found by Grep.
+type GrepMatch struct {
+	// Path is the name of the file, as given by Walk.
+	Path string
+	// Line and Column are the line number and the byte offset in the line
+	// of the match, counting from 1.
+	Line, Column int
+	// Text is the line holding the match, without its line ending, and
+	// Match is the matched part of it.
+	Text, Match []byte
+}
+
+// GrepFunc is the type of the function called by Grep for each match.
+// Grep stops, and returns the error, if the function returns one.
+type GrepFunc func(match GrepMatch) error
+
+func (a Afero) Grep(ctx context.Context, root string, m Matcher, fn GrepFunc) error {
+	return Grep(ctx, a.Fs, root, m, fn)
+}
+
+// Grep walks the file tree rooted at root, as Walk does, and calls fn for
+// each match of m in the lines of the files, in lexical order of the files
+// and in order within them. A *regexp.Regexp is a Matcher, and so is the
+// Matcher returned by BytesMatcher.
+//
+// Grep stops when ctx is done, and returns ctx.Err(). The errors walking
+// the tree or reading the files are returned as well.
+func Grep(ctx context.Context, fs Fs, root string, m Matcher, fn GrepFunc) error {
+	return Walk(fs, root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		f, err := fs.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return grepReader(ctx, f, path, m, fn)
+	})
+}
+
+func grepReader(ctx context.Context, r io.Reader, path string, m Matcher, fn GrepFunc) error {
+	br := bufio.NewReader(r)
+	for n := 1; ; n++ {
+		line, err := br.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if len(line) == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		text := bytes.TrimSuffix(bytes.TrimSuffix(line, []byte("\n")), []byte("\r"))
+		for _, loc := range m.FindAllIndex(text, -1) {
+			match := GrepMatch{
+				Path:   path,
+				Line:   n,
+				Column: loc[0] + 1,
+				Text:   text,
+				Match:  text[loc[0]:loc[1]],
+			}
+			if err := fn(match); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package afero
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestGrep(t *testing.T) {
+	fs := NewMemMapFs()
+	for name, content := range map[string]string{
+		"/src/a.go":     "package a\r\n\nfunc foo() { bar() }\n",
+		"/src/b/b.go":   "package b\nvar x = foo",
+		"/src/b/c.txt":  "no match\n",
+		"/other/d.go":   "func foo()\n",
+		"/src/b/e.data": "\x00\xfffoo\xff\n",
+	} {
+		if err := WriteFile(fs, name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	grep := func(m Matcher) []string {
+		var got []string
+		err := Grep(context.Background(), fs, "/src", m, func(match GrepMatch) error {
+			got = append(got, fmt.Sprintf("%s:%d:%d:%s", match.Path, match.Line, match.Column, match.Match))
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+
+	got := grep(regexp.MustCompile(`(foo|bar)\(\)|^package`))
+	want := []string{
+		"/src/a.go:1:1:package",
+		"/src/a.go:3:6:foo()",
+		"/src/a.go:3:14:bar()",
+		"/src/b/b.go:1:1:package",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	got = grep(BytesMatcher([]byte("foo"), []byte("fo"), []byte("\xff")))
+	want = []string{
+		"/src/a.go:3:6:foo",
+		"/src/b/b.go:2:9:foo",
+		"/src/b/e.data:1:2:\xff",
+		"/src/b/e.data:1:3:foo",
+		"/src/b/e.data:1:6:\xff",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	var text string
+	Grep(context.Background(), fs, "/src/a.go", BytesMatcher([]byte("package")), func(match GrepMatch) error {
+		text = string(match.Text)
+		return nil
+	})
+	if text != "package a" {
+		t.Errorf("got text %q", text)
+	}
+
+	stop := errors.New("stop")
+	matches := 0
+	err := Grep(context.Background(), fs, "/", BytesMatcher([]byte("foo")), func(match GrepMatch) error {
+		matches++
+		return stop
+	})
+	if err != stop || matches != 1 {
+		t.Errorf("got %d matches, %v", matches, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	matches = 0
+	err = Grep(ctx, fs, "/", BytesMatcher([]byte("foo")), func(match GrepMatch) error {
+		matches++
+		cancel()
+		return nil
+	})
+	if err != context.Canceled || matches != 1 {
+		t.Errorf("got %d matches, %v", matches, err)
+	}
+
+	if err := Grep(context.Background(), fs, "/missing", BytesMatcher([]byte("foo")), func(GrepMatch) error {
+		return nil
+	}); err == nil {
+		t.Error("expected an error for a missing root")
+	}
+}
@@ -0,0 +1,82 @@
+// Disabling building of hcl support in cases where golang is 1.0 or 1.1
+// as the encoding library is not implemented or supported.
+
+// +build go1.2
+
+package altsrc
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl"
+	"gopkg.in/urfave/cli.v1"
+)
+
+type hclSourceContext struct {
+	FilePath string
+}
+
+// NewHCLSourceFromFile creates a new HCL InputSourceContext from a filepath.
+func NewHCLSourceFromFile(file string) (InputSourceContext, error) {
+	return newHCLSource(defaultRemoteLoader, file)
+}
+
+func newHCLSource(loader *RemoteLoader, file string) (InputSourceContext, error) {
+	hsc := &hclSourceContext{FilePath: file}
+	results, err := readCommandHCL(loader, hsc.FilePath)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to load HCL file '%s': inner error: \n'%v'", hsc.FilePath, err.Error())
+	}
+	return newMapInputSource(hsc.FilePath, results), nil
+}
+
+// NewHCLSourceFromFlagFunc creates a new HCL InputSourceContext from a provided flag name and source context.
+func NewHCLSourceFromFlagFunc(flagFileName string) func(context *cli.Context) (InputSourceContext, error) {
+	return func(context *cli.Context) (InputSourceContext, error) {
+		filePath := context.String(flagFileName)
+		return NewHCLSourceFromFile(filePath)
+	}
+}
+
+func readCommandHCL(loader *RemoteLoader, filePath string) (map[interface{}]interface{}, error) {
+	b, err := loadDataFrom(loader, filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var object map[string]interface{}
+	if err := hcl.Unmarshal(b, &object); err != nil {
+		return nil, err
+	}
+	return hclValue(object).(map[interface{}]interface{}), nil
+}
+
+// hclValue converts a decoded HCL value into the types the other sources
+// hold. HCL decodes every block into a list of objects, as blocks may be
+// repeated, so the objects of a list are merged into a single map, with
+// the later blocks taking precedence.
+func hclValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		ret := make(map[interface{}]interface{}, len(v))
+		for key, val := range v {
+			ret[key] = hclValue(val)
+		}
+		return ret
+	case []map[string]interface{}:
+		ret := make(map[interface{}]interface{})
+		for _, object := range v {
+			for key, val := range hclValue(object).(map[interface{}]interface{}) {
+				ret[key] = val
+			}
+		}
+		return ret
+	case []interface{}:
+		ret := make([]interface{}, len(v))
+		for i, val := range v {
+			ret[i] = hclValue(val)
+		}
+		return ret
+	}
+	return v
+}
@@ -0,0 +1,311 @@
+// Copyright © 2014 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build go1.16
+
+package afero
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// IOFS is a read only Fs serving the files of an io/fs.FS, such as an
+// embed.FS, so that they can be handed to the code written against Fs.
+// The names are taken relative to the root of the io/fs.FS, whether they
+// start with a slash or not.
+type IOFS struct {
+	fsys fs.FS
+}
+
+func FromIOFS(fsys fs.FS) Fs {
+	return &IOFS{fsys: fsys}
+}
+
+// ioName converts an Fs name into the name of the file in the io/fs.FS.
+func ioName(name string) string {
+	name = path.Clean("/" + filepath.ToSlash(name))
+	if name == "/" {
+		return "."
+	}
+	return name[1:]
+}
+
+func (i *IOFS) Name() string { return "IOFS" }
+
+func (i *IOFS) Create(name string) (File, error) {
+	return nil, &os.PathError{Op: "create", Path: name, Err: syscall.EPERM}
+}
+
+func (i *IOFS) Mkdir(name string, perm os.FileMode) error {
+	return &os.PathError{Op: "mkdir", Path: name, Err: syscall.EPERM}
+}
+
+func (i *IOFS) MkdirAll(path string, perm os.FileMode) error {
+	return &os.PathError{Op: "mkdir", Path: path, Err: syscall.EPERM}
+}
+
+func (i *IOFS) Open(name string) (File, error) {
+	f, err := i.fsys.Open(ioName(name))
+	if err != nil {
+		return nil, err
+	}
+	return &ioFile{File: f, name: name}, nil
+}
+
+func (i *IOFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_APPEND|os.O_CREATE|os.O_TRUNC) != 0 {
+		return nil, &os.PathError{Op: "open", Path: name, Err: syscall.EPERM}
+	}
+	return i.Open(name)
+}
+
+func (i *IOFS) Remove(name string) error {
+	return &os.PathError{Op: "remove", Path: name, Err: syscall.EPERM}
+}
+
+func (i *IOFS) RemoveAll(path string) error {
+	return &os.PathError{Op: "remove", Path: path, Err: syscall.EPERM}
+}
+
+func (i *IOFS) Rename(oldname, newname string) error {
+	return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: syscall.EPERM}
+}
+
+func (i *IOFS) Stat(name string) (os.FileInfo, error) {
+	return fs.Stat(i.fsys, ioName(name))
+}
+
+func (i *IOFS) Chmod(name string, mode os.FileMode) error {
+	return &os.PathError{Op: "chmod", Path: name, Err: syscall.EPERM}
+}
+
+func (i *IOFS) Chtimes(name string, atime time.Time, mtime time.Time) error {
+	return &os.PathError{Op: "chtimes", Path: name, Err: syscall.EPERM}
+}
+
+// ioFile is the File of an IOFS. The files of the io/fs.FS which do not
+// implement io.ReaderAt, io.Seeker or fs.ReadDirFile fail these calls.
+type ioFile struct {
+	fs.File
+	name string
+}
+
+func (f *ioFile) Name() string { return f.name }
+
+func (f *ioFile) ReadAt(p []byte, off int64) (int, error) {
+	if r, ok := f.File.(io.ReaderAt); ok {
+		return r.ReadAt(p, off)
+	}
+	return 0, &os.PathError{Op: "readat", Path: f.name, Err: syscall.EINVAL}
+}
+
+func (f *ioFile) Seek(offset int64, whence int) (int64, error) {
+	if s, ok := f.File.(io.Seeker); ok {
+		return s.Seek(offset, whence)
+	}
+	return 0, &os.PathError{Op: "seek", Path: f.name, Err: syscall.EINVAL}
+}
+
+func (f *ioFile) Readdir(count int) ([]os.FileInfo, error) {
+	d, ok := f.File.(fs.ReadDirFile)
+	if !ok {
+		return nil, &os.PathError{Op: "readdir", Path: f.name, Err: syscall.ENOTDIR}
+	}
+	entries, err := d.ReadDir(count)
+	fis := make([]os.FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		fi, ierr := entry.Info()
+		if ierr != nil {
+			return fis, ierr
+		}
+		fis = append(fis, fi)
+	}
+	return fis, err
+}
+
+func (f *ioFile) Readdirnames(n int) ([]string, error) {
+	fis, err := f.Readdir(n)
+	names := make([]string, len(fis))
+	for i, fi := range fis {
+		names[i] = fi.Name()
+	}
+	return names, err
+}
+
+func (f *ioFile) Sync() error { return nil }
+
+func (f *ioFile) Truncate(size int64) error {
+	return &os.PathError{Op: "truncate", Path: f.name, Err: syscall.EPERM}
+}
+
+func (f *ioFile) Write(p []byte) (int, error) {
+	return 0, &os.PathError{Op: "write", Path: f.name, Err: syscall.EPERM}
+}
+
+func (f *ioFile) WriteAt(p []byte, off int64) (int, error) {
+	return 0, &os.PathError{Op: "write", Path: f.name, Err: syscall.EPERM}
+}
+
+func (f *ioFile) WriteString(s string) (int, error) {
+	return 0, &os.PathError{Op: "write", Path: f.name, Err: syscall.EPERM}
+}
+
+// ToIOFS returns an io/fs.FS serving the files of the Fs, which also
+// implements fs.ReadDirFS, fs.StatFS and fs.GlobFS. The root of the
+// io/fs.FS is the root of the Fs: a directory of the OsFs is served with
+// a BasePathFs.
+func ToIOFS(afs Fs) fs.FS {
+	return ioFS{afs}
+}
+
+type ioFS struct {
+	Fs
+}
+
+var (
+	_ fs.ReadDirFS = ioFS{}
+	_ fs.StatFS    = ioFS{}
+	_ fs.GlobFS    = ioFS{}
+)
+
+// fsName converts the name of a file of an io/fs.FS into an Fs name.
+func fsName(op, name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	return filepath.FromSlash(path.Join("/", name)), nil
+}
+
+// pathError names the file of the io/fs.FS in the errors of the Fs.
+func pathError(op, name string, err error) error {
+	if e, ok := err.(*os.PathError); ok {
+		err = e.Err
+	}
+	return &fs.PathError{Op: op, Path: name, Err: err}
+}
+
+func (i ioFS) Open(name string) (fs.File, error) {
+	fname, err := fsName("open", name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := i.Fs.Open(fname)
+	if err != nil {
+		return nil, pathError("open", name, err)
+	}
+	return ioFSFile{File: f, name: name}, nil
+}
+
+func (i ioFS) Stat(name string) (fs.FileInfo, error) {
+	fname, err := fsName("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := i.Fs.Stat(fname)
+	if err != nil {
+		return nil, pathError("stat", name, err)
+	}
+	return dirInfo{fi}, nil
+}
+
+func (i ioFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	fname, err := fsName("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	fis, err := ReadDir(i.Fs, fname)
+	if err != nil {
+		return nil, pathError("readdir", name, err)
+	}
+	return dirEntries(fis), nil
+}
+
+func (i ioFS) Glob(pattern string) ([]string, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	matches, err := Glob(i.Fs, filepath.FromSlash(path.Join("/", pattern)))
+	for j, match := range matches {
+		matches[j] = strings.TrimPrefix(filepath.ToSlash(match), "/")
+	}
+	return matches, err
+}
+
+// ioFSFile is the fs.File of an ioFS. It keeps the methods of the File,
+// such as Seek, which http.FS relies on.
+type ioFSFile struct {
+	File
+	name string
+}
+
+func (f ioFSFile) Stat() (fs.FileInfo, error) {
+	fi, err := f.File.Stat()
+	if err != nil {
+		return nil, pathError("stat", f.name, err)
+	}
+	return dirInfo{fi}, nil
+}
+
+// ReadAt reports the short reads with io.EOF, as io.ReaderAt requires,
+// which not every Fs does.
+func (f ioFSFile) ReadAt(p []byte, off int64) (int, error) {
+	n, err := f.File.ReadAt(p, off)
+	if err == nil && n < len(p) {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func (f ioFSFile) ReadDir(count int) ([]fs.DirEntry, error) {
+	fis, err := f.File.Readdir(count)
+	if err != nil && err != io.EOF {
+		err = pathError("readdir", f.name, err)
+	}
+	return dirEntries(fis), err
+}
+
+// dirInfo is an os.FileInfo whose mode has os.ModeDir set for the
+// directories, which not every Fs does.
+type dirInfo struct {
+	os.FileInfo
+}
+
+func (fi dirInfo) Mode() fs.FileMode {
+	if fi.IsDir() {
+		return fi.FileInfo.Mode() | fs.ModeDir
+	}
+	return fi.FileInfo.Mode()
+}
+
+// dirEntry is the fs.DirEntry of an os.FileInfo.
+type dirEntry struct {
+	dirInfo
+}
+
+func (d dirEntry) Type() fs.FileMode          { return d.Mode().Type() }
+func (d dirEntry) Info() (fs.FileInfo, error) { return d.dirInfo, nil }
+
+func dirEntries(fis []os.FileInfo) []fs.DirEntry {
+	entries := make([]fs.DirEntry, len(fis))
+	for i, fi := range fis {
+		entries[i] = dirEntry{dirInfo{fi}}
+	}
+	return entries
+}
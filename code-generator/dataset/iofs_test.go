@@ -0,0 +1,105 @@
+// +build go1.16
+
+package afero
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"reflect"
+	"syscall"
+	"testing"
+	"testing/fstest"
+)
+
+func TestToIOFS(t *testing.T) {
+	afs := NewMemMapFs()
+	for name, content := range map[string]string{
+		"/a.txt":         "a",
+		"/dir/b.txt":     "b",
+		"/dir/sub/c.txt": "c",
+	} {
+		if err := WriteFile(afs, name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fsys := ToIOFS(afs)
+	if err := fstest.TestFS(fsys, "a.txt", "dir/b.txt", "dir/sub/c.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	matches, err := fs.Glob(fsys, "dir/*.txt")
+	if err != nil || !reflect.DeepEqual(matches, []string{"dir/b.txt"}) {
+		t.Errorf("got %v, %v", matches, err)
+	}
+	if _, err := fs.Glob(fsys, "[a"); err != path.ErrBadPattern {
+		t.Errorf("expected ErrBadPattern, got %v", err)
+	}
+	if _, err := fs.Stat(fsys, "missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected ErrNotExist, got %v", err)
+	}
+	if _, err := fsys.Open("/a.txt"); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("expected ErrInvalid, got %v", err)
+	}
+}
+
+func TestFromIOFS(t *testing.T) {
+	mapFS := fstest.MapFS{
+		"a.txt":         {Data: []byte("a"), Mode: 0644},
+		"dir/b.txt":     {Data: []byte("b"), Mode: 0644},
+		"dir/sub/c.txt": {Data: []byte("c"), Mode: 0644},
+	}
+	afs := FromIOFS(mapFS)
+
+	for _, name := range []string{"/dir/b.txt", "dir/b.txt"} {
+		content, err := ReadFile(afs, name)
+		if err != nil || string(content) != "b" {
+			t.Errorf("%s: got %q, %v", name, content, err)
+		}
+	}
+
+	f, err := afs.Open("/dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	names, err := f.Readdirnames(1)
+	if err != nil || !reflect.DeepEqual(names, []string{"b.txt"}) {
+		t.Errorf("got %v, %v", names, err)
+	}
+	names, err = f.Readdirnames(-1)
+	if err != nil || !reflect.DeepEqual(names, []string{"sub"}) {
+		t.Errorf("got %v, %v", names, err)
+	}
+	if _, err = f.Readdirnames(1); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+	f.Close()
+
+	if exists, err := DirExists(afs, "/dir/sub"); !exists || err != nil {
+		t.Errorf("got %v, %v", exists, err)
+	}
+	if _, err := afs.Stat("/missing"); !os.IsNotExist(err) {
+		t.Errorf("expected a not exist error, got %v", err)
+	}
+	if _, err := afs.Create("/new.txt"); !errors.Is(err, syscall.EPERM) {
+		t.Errorf("expected EPERM, got %v", err)
+	}
+	if err := afs.Remove("/a.txt"); !errors.Is(err, syscall.EPERM) {
+		t.Errorf("expected EPERM, got %v", err)
+	}
+
+	// and back, along with the rest of the afero helpers
+	if err := fstest.TestFS(ToIOFS(afs), "a.txt", "dir/b.txt", "dir/sub/c.txt"); err != nil {
+		t.Fatal(err)
+	}
+	copied := NewMemMapFs()
+	if err := CopyTree(afs, "/", copied, "/copy"); err != nil {
+		t.Fatal(err)
+	}
+	if content, err := ReadFile(copied, "/copy/dir/sub/c.txt"); err != nil || string(content) != "c" {
+		t.Errorf("got %q, %v", content, err)
+	}
+}
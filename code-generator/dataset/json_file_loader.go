@@ -0,0 +1,80 @@
+package altsrc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/urfave/cli.v1"
+)
+
+type jsonSourceContext struct {
+	FilePath string
+}
+
+// NewJSONSourceFromFile creates a new JSON InputSourceContext from a filepath.
+func NewJSONSourceFromFile(file string) (InputSourceContext, error) {
+	return newJSONSource(defaultRemoteLoader, file)
+}
+
+func newJSONSource(loader *RemoteLoader, file string) (InputSourceContext, error) {
+	jsc := &jsonSourceContext{FilePath: file}
+	results, err := readCommandJSON(loader, jsc.FilePath)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to load JSON file '%s': inner error: \n'%v'", jsc.FilePath, err.Error())
+	}
+	return newMapInputSource(jsc.FilePath, results), nil
+}
+
+// NewJSONSourceFromFlagFunc creates a new JSON InputSourceContext from a provided flag name and source context.
+func NewJSONSourceFromFlagFunc(flagFileName string) func(context *cli.Context) (InputSourceContext, error) {
+	return func(context *cli.Context) (InputSourceContext, error) {
+		filePath := context.String(flagFileName)
+		return NewJSONSourceFromFile(filePath)
+	}
+}
+
+func readCommandJSON(loader *RemoteLoader, filePath string) (map[interface{}]interface{}, error) {
+	b, err := loadDataFrom(loader, filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var object map[string]interface{}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	if err := d.Decode(&object); err != nil {
+		return nil, err
+	}
+	if d.More() {
+		return nil, fmt.Errorf("unexpected data after the top-level object")
+	}
+	return jsonValue(object).(map[interface{}]interface{}), nil
+}
+
+// jsonValue converts a decoded JSON value into the types the other
+// sources hold: whole numbers become ints and objects become maps keyed
+// by interface{}.
+func jsonValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		ret := make(map[interface{}]interface{}, len(v))
+		for key, val := range v {
+			ret[key] = jsonValue(val)
+		}
+		return ret
+	case []interface{}:
+		ret := make([]interface{}, len(v))
+		for i, val := range v {
+			ret[i] = jsonValue(val)
+		}
+		return ret
+	case json.Number:
+		if i, err := v.Int64(); err == nil && int64(int(i)) == i {
+			return int(i)
+		}
+		f, _ := v.Float64()
+		return f
+	}
+	return v
+}
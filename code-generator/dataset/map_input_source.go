@@ -0,0 +1,312 @@
+package altsrc
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/urfave/cli.v1"
+)
+
+// MapInputSource implements InputSourceContext to return
+// data from the map that is loaded. It reads the values of the map into
+// the types of the flags as leniently as the file formats require, such
+// as durations out of strings and floats out of whole numbers, and names
+// the file and the key in its errors.
+type MapInputSource struct {
+	valueMap map[interface{}]interface{}
+	file     string // The file the map was loaded from, if any.
+	text     bool   // The values are strings, converted as they are read.
+}
+
+func newMapInputSource(file string, valueMap map[interface{}]interface{}) *MapInputSource {
+	if valueMap == nil {
+		valueMap = make(map[interface{}]interface{})
+	}
+	return &MapInputSource{valueMap: valueMap, file: file}
+}
+
+// nestedVal checks if the name has '.' delimiters.
+// If so, it tries to traverse the tree by the '.' delimited sections to find
+// a nested value for the key.
+func nestedVal(name string, tree map[interface{}]interface{}) (interface{}, bool) {
+	if sections := strings.Split(name, "."); len(sections) > 1 {
+		node := tree
+		for _, section := range sections[:len(sections)-1] {
+			if child, ok := node[section]; !ok {
+				return nil, false
+			} else {
+				if ctype, ok := child.(map[interface{}]interface{}); !ok {
+					return nil, false
+				} else {
+					node = ctype
+				}
+			}
+		}
+		if val, ok := node[sections[len(sections)-1]]; ok {
+			return val, true
+		}
+	}
+	return nil, false
+}
+
+// lookup returns the value of the key with the given name.
+func (fsm *MapInputSource) lookup(name string) (interface{}, bool) {
+	if value, exists := fsm.valueMap[name]; exists {
+		return value, true
+	}
+	return nestedVal(name, fsm.valueMap)
+}
+
+func (fsm *MapInputSource) isSet(name string) bool {
+	_, exists := fsm.lookup(name)
+	return exists
+}
+
+func (fsm *MapInputSource) typeError(name, expectedTypeName string, value interface{}) error {
+	return &typeMismatchError{file: fsm.file, key: name, expected: expectedTypeName, value: value}
+}
+
+// typeMismatchError is returned when the value of a key does not suit the
+// flag of the same name.
+type typeMismatchError struct {
+	file, key, expected string
+	value               interface{}
+}
+
+func (e *typeMismatchError) Error() string {
+	if e.file == "" {
+		return fmt.Sprintf("Mismatched type for key '%s'. Expected %s but actual is %s", e.key, e.expected, describeValue(e.value))
+	}
+	return fmt.Sprintf("Mismatched type for key '%s' in '%s'. Expected %s but actual is %s", e.key, e.file, e.expected, describeValue(e.value))
+}
+
+// describeValue names the type of value along with the value itself.
+func describeValue(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case map[interface{}]interface{}:
+		return "a set of keys"
+	case []interface{}:
+		return "a list"
+	case string:
+		return fmt.Sprintf("string %q", value)
+	}
+	return fmt.Sprintf("%T %v", value, value)
+}
+
+// toInt converts the whole numbers held by the sources into an int.
+func toInt(value interface{}) (int, bool) {
+	switch value := value.(type) {
+	case int:
+		return value, true
+	case int64:
+		if int64(int(value)) == value {
+			return int(value), true
+		}
+	case uint64:
+		if value <= uint64(int(^uint(0)>>1)) {
+			return int(value), true
+		}
+	}
+	return 0, false
+}
+
+// Int returns an int from the map if it exists otherwise returns 0
+func (fsm *MapInputSource) Int(name string) (int, error) {
+	value, exists := fsm.lookup(name)
+	if !exists {
+		return 0, nil
+	}
+	if s, isType := value.(string); isType && fsm.text {
+		if i, err := strconv.Atoi(s); err == nil {
+			return i, nil
+		}
+	}
+	i, ok := toInt(value)
+	if !ok {
+		return 0, fsm.typeError(name, "an integer", value)
+	}
+	return i, nil
+}
+
+// Duration returns a duration from the map if it exists otherwise returns
+// 0. Durations are given as strings such as "1h30m".
+func (fsm *MapInputSource) Duration(name string) (time.Duration, error) {
+	value, exists := fsm.lookup(name)
+	if !exists {
+		return 0, nil
+	}
+	switch v := value.(type) {
+	case time.Duration:
+		return v, nil
+	case string:
+		if d, err := time.ParseDuration(v); err == nil {
+			return d, nil
+		}
+	}
+	return 0, fsm.typeError(name, "a duration such as \"1h30m\"", value)
+}
+
+// Float64 returns a float64 from the map if it exists otherwise returns 0
+func (fsm *MapInputSource) Float64(name string) (float64, error) {
+	value, exists := fsm.lookup(name)
+	if !exists {
+		return 0, nil
+	}
+	if f, ok := value.(float64); ok {
+		return f, nil
+	}
+	if s, isType := value.(string); isType && fsm.text {
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f, nil
+		}
+	}
+	if i, ok := toInt(value); ok {
+		return float64(i), nil
+	}
+	return 0, fsm.typeError(name, "a number", value)
+}
+
+// String returns a string from the map if it exists otherwise returns an empty string
+func (fsm *MapInputSource) String(name string) (string, error) {
+	value, exists := fsm.lookup(name)
+	if !exists {
+		return "", nil
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", fsm.typeError(name, "a string", value)
+	}
+	return s, nil
+}
+
+// StringSlice returns a []string from the map if it exists otherwise returns nil
+func (fsm *MapInputSource) StringSlice(name string) ([]string, error) {
+	value, exists := fsm.lookup(name)
+	if !exists {
+		return nil, nil
+	}
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, fsm.typeError(name, "a list of strings", value)
+	}
+	stringSlice := make([]string, 0, len(list))
+	for i, v := range list {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fsm.typeError(fmt.Sprintf("%s[%d]", name, i), "a string", v)
+		}
+		stringSlice = append(stringSlice, s)
+	}
+	return stringSlice, nil
+}
+
+// IntSlice returns a []int from the map if it exists otherwise returns nil
+func (fsm *MapInputSource) IntSlice(name string) ([]int, error) {
+	value, exists := fsm.lookup(name)
+	if !exists {
+		return nil, nil
+	}
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, fsm.typeError(name, "a list of integers", value)
+	}
+	intSlice := make([]int, 0, len(list))
+	for i, v := range list {
+		n, ok := toInt(v)
+		if !ok {
+			return nil, fsm.typeError(fmt.Sprintf("%s[%d]", name, i), "an integer", v)
+		}
+		intSlice = append(intSlice, n)
+	}
+	return intSlice, nil
+}
+
+// Generic returns a cli.Generic holding the text of the value from the
+// map if it exists otherwise returns nil. The flag is set to the value
+// as if it was given on the command line.
+func (fsm *MapInputSource) Generic(name string) (cli.Generic, error) {
+	value, exists := fsm.lookup(name)
+	if !exists {
+		return nil, nil
+	}
+	switch v := value.(type) {
+	case cli.Generic:
+		return v, nil
+	case string:
+		return &textValue{v}, nil
+	case bool:
+		return &textValue{strconv.FormatBool(v)}, nil
+	case float64:
+		return &textValue{float64ToString(v)}, nil
+	case time.Time:
+		return &textValue{v.Format(time.RFC3339Nano)}, nil
+	}
+	if i, ok := toInt(value); ok {
+		return &textValue{strconv.Itoa(i)}, nil
+	}
+	return nil, fsm.typeError(name, "a single value", value)
+}
+
+// Bool returns a bool from the map if it exists otherwise returns false
+func (fsm *MapInputSource) Bool(name string) (bool, error) {
+	return fsm.bool(name, false)
+}
+
+// BoolT returns a bool from the map if it exists otherwise returns true
+func (fsm *MapInputSource) BoolT(name string) (bool, error) {
+	return fsm.bool(name, true)
+}
+
+func (fsm *MapInputSource) bool(name string, missing bool) (bool, error) {
+	value, exists := fsm.lookup(name)
+	if !exists {
+		return missing, nil
+	}
+	if s, isType := value.(string); isType && fsm.text {
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b, nil
+		}
+	}
+	b, ok := value.(bool)
+	if !ok {
+		return missing, fsm.typeError(name, "a boolean", value)
+	}
+	return b, nil
+}
+
+// Timestamp returns a time from the map if it exists otherwise returns the
+// zero time. Timestamps are given either natively, as in TOML, or as
+// strings in the given layout.
+func (fsm *MapInputSource) Timestamp(name, layout string) (time.Time, error) {
+	value, exists := fsm.lookup(name)
+	if !exists {
+		return time.Time{}, nil
+	}
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case string:
+		if t, err := time.Parse(layout, v); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fsm.typeError(name, fmt.Sprintf("a timestamp in the layout %q", layout), value)
+}
+
+// textValue is a cli.Generic holding the text of a value.
+type textValue struct {
+	text string
+}
+
+func (v *textValue) Set(text string) error {
+	v.text = text
+	return nil
+}
+
+func (v *textValue) String() string {
+	return v.text
+}
@@ -0,0 +1,120 @@
+package altsrc
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/urfave/cli.v1"
+)
+
+func runTypedFlagsCommand(t *testing.T, file string, data string, newSource func(string) func(*cli.Context) (InputSourceContext, error), action func(c *cli.Context)) error {
+	ioutil.WriteFile(file, []byte(data), 0666)
+	defer os.Remove(file)
+
+	app := cli.NewApp()
+	set := flag.NewFlagSet("test", 0)
+	test := []string{"test-cmd", "--load", file}
+	set.Parse(test)
+
+	c := cli.NewContext(app, set, nil)
+
+	command := &cli.Command{
+		Name:        "test-cmd",
+		Aliases:     []string{"tc"},
+		Usage:       "this is for testing",
+		Description: "testing",
+		Action: func(c *cli.Context) error {
+			action(c)
+			return nil
+		},
+		Flags: []cli.Flag{
+			NewStringSliceFlag(cli.StringSliceFlag{Name: "hosts"}),
+			NewIntSliceFlag(cli.IntSliceFlag{Name: "top.ports"}),
+			NewDurationFlag(cli.DurationFlag{Name: "timeout"}),
+			NewFloat64Flag(cli.Float64Flag{Name: "rate"}),
+			NewGenericFlag(cli.GenericFlag{Name: "pair", Value: &Parser{}}),
+			NewTimestampFlag(cli.GenericFlag{Name: "since", Value: NewTimestamp("2006-01-02", time.Time{})}),
+			cli.StringFlag{Name: "load"}},
+	}
+	command.Before = InitInputSourceWithContext(command.Flags, newSource("load"))
+
+	return command.Run(c)
+}
+
+func checkTypedFlags(t *testing.T, c *cli.Context) {
+	expect(t, c.StringSlice("hosts"), []string{"a", "b"})
+	expect(t, c.IntSlice("top.ports"), []int{80, 443})
+	expect(t, c.Duration("timeout"), 90*time.Second)
+	expect(t, c.Float64("rate"), 2.0)
+	expect(t, c.Generic("pair"), &Parser{"x", "y"})
+	expect(t, c.Generic("since").(*Timestamp).Value(), time.Date(2017, 3, 4, 0, 0, 0, 0, time.UTC))
+}
+
+func TestCommandYamlFileTypedFlags(t *testing.T) {
+	data := "hosts: [a, b]\ntop:\n  ports: [80, 443]\ntimeout: 1m30s\nrate: 2\npair: x,y\nsince: 2017-03-04\n"
+	err := runTypedFlagsCommand(t, "current.yaml", data, NewYamlSourceFromFlagFunc, func(c *cli.Context) {
+		checkTypedFlags(t, c)
+	})
+	expect(t, err, nil)
+}
+
+func TestCommandTomlFileTypedFlags(t *testing.T) {
+	data := "hosts = [\"a\", \"b\"]\ntimeout = \"1m30s\"\nrate = 2\npair = \"x,y\"\nsince = 2017-03-04T00:00:00Z\n\n[top]\nports = [80, 443]\n\n[[servers]]\nname = \"a\"\n"
+	err := runTypedFlagsCommand(t, "current.toml", data, NewTomlSourceFromFlagFunc, func(c *cli.Context) {
+		checkTypedFlags(t, c)
+	})
+	expect(t, err, nil)
+}
+
+func TestCommandTypedFlagsErrors(t *testing.T) {
+	for data, msg := range map[string]string{
+		"hosts: [a, 1]\n":     "Mismatched type for key 'hosts[1]' in 'current.yaml'. Expected a string but actual is int 1",
+		"top: {ports: 80}\n":  "Mismatched type for key 'top.ports' in 'current.yaml'. Expected a list of integers but actual is int 80",
+		"timeout: 90\n":       "Mismatched type for key 'timeout' in 'current.yaml'. Expected a duration such as \"1h30m\" but actual is int 90",
+		"rate: fast\n":        "Mismatched type for key 'rate' in 'current.yaml'. Expected a number but actual is string \"fast\"",
+		"pair: [x, y]\n":      "Mismatched type for key 'pair' in 'current.yaml'. Expected a single value but actual is a list",
+		"since: 04/03/2017\n": "Mismatched type for key 'since' in 'current.yaml'. Expected a timestamp in the layout \"2006-01-02\" but actual is string \"04/03/2017\"",
+	} {
+		err := runTypedFlagsCommand(t, "current.yaml", data, NewYamlSourceFromFlagFunc, func(c *cli.Context) {
+			t.Errorf("loading %q: unexpected run", data)
+		})
+		if err == nil || !strings.Contains(err.Error(), msg) {
+			t.Errorf("loading %q: expected error %q, got %v", data, msg, err)
+		}
+	}
+}
+
+func TestFileSourcesAreMapInputSources(t *testing.T) {
+	for _, load := range []struct {
+		file, data string
+		newSource  func(string) (InputSourceContext, error)
+	}{
+		{"current.toml", "test = 15\n", NewTomlSourceFromFile},
+		{"current.yaml", "test: 15\n", NewYamlSourceFromFile},
+		{"current.json", `{"test": 15}`, NewJSONSourceFromFile},
+		{"current.hcl", "test = 15\n", NewHCLSourceFromFile},
+		{"current.env", "test=15\n", NewDotenvSourceFromFile},
+	} {
+		ioutil.WriteFile(load.file, []byte(load.data), 0666)
+		isc, err := load.newSource(load.file)
+		os.Remove(load.file)
+		expect(t, err, nil)
+		mis, isType := isc.(*MapInputSource)
+		if !isType {
+			t.Errorf("%s: expected a *MapInputSource, got %T", load.file, isc)
+			continue
+		}
+		i, err := mis.Int("test")
+		expect(t, err, nil)
+		expect(t, i, 15)
+	}
+
+	// Maps not loaded from a file have no file to name in their errors.
+	mis := &MapInputSource{valueMap: map[interface{}]interface{}{"test": "x"}}
+	_, err := mis.Int("test")
+	expect(t, err.Error(), "Mismatched type for key 'test'. Expected an integer but actual is string \"x\"")
+}
@@ -0,0 +1,403 @@
+package afero
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// WhiteoutPrefix starts the name of the marker file in the overlay which
+	// hides the file of the base named by the rest of the marker name.
+	WhiteoutPrefix = ".wh."
+	// WhiteoutOpaqueDir is the name of the marker file in an overlay
+	// directory which hides the whole content of the base directory.
+	WhiteoutOpaqueDir = WhiteoutPrefix + WhiteoutPrefix + ".opq"
+)
+
+// The OverlayFs is a CopyOnWriteFs which also removes and renames the
+// files of the read only base, like overlayfs does: removing a file of the
+// base leaves a whiteout marker in the overlay, which hides the file from
+// Stat(), Open() and the Readdir() of the UnionFile, and a directory
+// created in place of a removed one is marked opaque, so that the former
+// content of the base does not show through.
+//
+// The markers are named as in the OCI image layers, WhiteoutPrefix and the
+// name of the hidden file, and WhiteoutOpaqueDir, so they are never shown
+// themselves.
+type OverlayFs struct {
+	base  Fs
+	layer Fs
+}
+
+func NewOverlayFs(base Fs, layer Fs) Fs {
+	return &OverlayFs{base: base, layer: layer}
+}
+
+func whiteoutName(name string) string {
+	return filepath.Join(filepath.Dir(name), WhiteoutPrefix+filepath.Base(name))
+}
+
+func isWhiteout(name string) bool {
+	return strings.HasPrefix(filepath.Base(name), WhiteoutPrefix)
+}
+
+func isNotExist(err error) bool {
+	if e, ok := err.(*os.PathError); ok {
+		err = e.Err
+	}
+	return os.IsNotExist(err) || err == syscall.ENOENT || err == syscall.ENOTDIR
+}
+
+func notExist(op, name string) error {
+	return &os.PathError{Op: op, Path: name, Err: os.ErrNotExist}
+}
+
+func (u *OverlayFs) inLayer(name string) bool {
+	_, err := u.layer.Stat(name)
+	return err == nil
+}
+
+// Returns true if the base file at name, or one of its parents, is hidden
+// by a whiteout or an opaque directory of the overlay
+func (u *OverlayFs) isHidden(name string) bool {
+	for name = filepath.Clean(name); ; {
+		if u.inLayer(whiteoutName(name)) {
+			return true
+		}
+		parent := filepath.Dir(name)
+		if parent == name {
+			return false
+		}
+		if u.inLayer(filepath.Join(parent, WhiteoutOpaqueDir)) {
+			return true
+		}
+		name = parent
+	}
+}
+
+func (u *OverlayFs) inBase(name string) bool {
+	if u.isHidden(name) {
+		return false
+	}
+	_, err := u.base.Stat(name)
+	return err == nil
+}
+
+// Returns true if the file is not in the overlay and not hidden by it
+func (u *OverlayFs) isBaseFile(name string) (bool, error) {
+	if u.inLayer(name) || u.isHidden(name) {
+		return false, nil
+	}
+	_, err := u.base.Stat(name)
+	if err != nil {
+		if isNotExist(err) {
+			return false, nil
+		}
+	}
+	return true, err
+}
+
+// whiteout hides the base file at name.
+func (u *OverlayFs) whiteout(name string) error {
+	if err := u.layer.MkdirAll(filepath.Dir(name), 0777); err != nil {
+		return err
+	}
+	f, err := u.layer.Create(whiteoutName(name))
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// cover drops the whiteout of name, once the overlay holds a new file at
+// name. A new directory is marked opaque instead, so that it does not
+// show the content of the base directory it replaces.
+func (u *OverlayFs) cover(name string, dir bool) error {
+	wh := whiteoutName(name)
+	if !u.inLayer(wh) {
+		return nil
+	}
+	if err := u.layer.Remove(wh); err != nil {
+		return err
+	}
+	if !dir {
+		return nil
+	}
+	f, err := u.layer.Create(filepath.Join(name, WhiteoutOpaqueDir))
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func (u *OverlayFs) copyToLayer(name string) error {
+	return copyToLayer(u.base, u.layer, name)
+}
+
+func (u *OverlayFs) Chtimes(name string, atime, mtime time.Time) error {
+	b, err := u.isBaseFile(name)
+	if err != nil {
+		return err
+	}
+	if b {
+		if err := u.copyToLayer(name); err != nil {
+			return err
+		}
+	}
+	return u.layer.Chtimes(name, atime, mtime)
+}
+
+func (u *OverlayFs) Chmod(name string, mode os.FileMode) error {
+	b, err := u.isBaseFile(name)
+	if err != nil {
+		return err
+	}
+	if b {
+		if err := u.copyToLayer(name); err != nil {
+			return err
+		}
+	}
+	return u.layer.Chmod(name, mode)
+}
+
+func (u *OverlayFs) Stat(name string) (os.FileInfo, error) {
+	if isWhiteout(name) {
+		return nil, notExist("stat", name)
+	}
+	fi, err := u.layer.Stat(name)
+	if err == nil {
+		return fi, nil
+	}
+	if !isNotExist(err) {
+		return nil, err
+	}
+	if u.isHidden(name) {
+		return nil, notExist("stat", name)
+	}
+	return u.base.Stat(name)
+}
+
+// Renaming a file of the base copies it to the overlay and leaves a
+// whiteout in its place. Like in overlayfs, renaming a directory of the
+// base fails with EXDEV, so that the caller falls back to copying it.
+func (u *OverlayFs) Rename(oldname, newname string) error {
+	fi, err := u.Stat(oldname)
+	if err != nil {
+		return err
+	}
+	if isWhiteout(newname) {
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: syscall.EINVAL}
+	}
+	inBase := u.inBase(oldname)
+	if inBase && fi.IsDir() {
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: syscall.EXDEV}
+	}
+	if !u.inLayer(oldname) {
+		if err := u.copyToLayer(oldname); err != nil {
+			return err
+		}
+	}
+	if err := u.layer.MkdirAll(filepath.Dir(newname), 0777); err != nil {
+		return err
+	}
+	if err := u.layer.Rename(oldname, newname); err != nil {
+		return err
+	}
+	if err := u.cover(newname, fi.IsDir()); err != nil {
+		return err
+	}
+	if inBase {
+		return u.whiteout(oldname)
+	}
+	return nil
+}
+
+// Removing a file of the base leaves a whiteout in the overlay. As usual,
+// directories must be empty to be removed.
+func (u *OverlayFs) Remove(name string) error {
+	fi, err := u.Stat(name)
+	if err != nil {
+		return err
+	}
+	if fi.IsDir() {
+		names, err := u.readDirNames(name)
+		if err != nil {
+			return err
+		}
+		if len(names) > 0 {
+			return &os.PathError{Op: "remove", Path: name, Err: syscall.ENOTEMPTY}
+		}
+	}
+	if u.inLayer(name) {
+		// an empty directory of the union may still hold whiteouts
+		if err := u.layer.RemoveAll(name); err != nil {
+			return err
+		}
+	}
+	if u.inBase(name) {
+		return u.whiteout(name)
+	}
+	return nil
+}
+
+func (u *OverlayFs) RemoveAll(name string) error {
+	if _, err := u.Stat(name); err != nil {
+		if isNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if err := u.layer.RemoveAll(name); err != nil {
+		return err
+	}
+	if u.inBase(name) {
+		return u.whiteout(name)
+	}
+	return nil
+}
+
+func (u *OverlayFs) readDirNames(name string) ([]string, error) {
+	f, err := u.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return f.Readdirnames(-1)
+}
+
+func (u *OverlayFs) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_APPEND|os.O_CREATE|os.O_TRUNC) == 0 {
+		return u.Open(name)
+	}
+	if isWhiteout(name) {
+		return nil, &os.PathError{Op: "open", Path: name, Err: syscall.EINVAL}
+	}
+
+	b, err := u.isBaseFile(name)
+	if err != nil {
+		return nil, err
+	}
+	if b {
+		if err = u.copyToLayer(name); err != nil {
+			return nil, err
+		}
+		return u.layer.OpenFile(name, flag, perm)
+	}
+	if u.inLayer(name) {
+		return u.layer.OpenFile(name, flag, perm)
+	}
+
+	dir := filepath.Dir(name)
+	fi, err := u.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return nil, &os.PathError{Op: "open", Path: name, Err: syscall.ENOTDIR}
+	}
+	if err = u.layer.MkdirAll(dir, 0777); err != nil {
+		return nil, err
+	}
+	f, err := u.layer.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	if err = u.cover(name, false); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// Directories of the overlay are always opened as a UnionFile, which
+// leaves out the whiteouts and the files of the base they hide.
+func (u *OverlayFs) Open(name string) (File, error) {
+	if isWhiteout(name) {
+		return nil, notExist("open", name)
+	}
+	b, err := u.isBaseFile(name)
+	if err != nil {
+		return nil, err
+	}
+	if b {
+		return u.base.Open(name)
+	}
+
+	dir, err := IsDir(u.layer, name)
+	if err != nil {
+		if isNotExist(err) {
+			return nil, notExist("open", name)
+		}
+		return nil, err
+	}
+	if !dir {
+		return u.layer.Open(name)
+	}
+
+	lfile, err := u.layer.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	if u.isHidden(name) {
+		return &UnionFile{layer: lfile, whiteouts: true}, nil
+	}
+	if dir, _ = IsDir(u.base, name); !dir {
+		return &UnionFile{layer: lfile, whiteouts: true}, nil
+	}
+	bfile, err := u.base.Open(name)
+	if err != nil {
+		lfile.Close()
+		return nil, err
+	}
+	return &UnionFile{base: bfile, layer: lfile, whiteouts: true}, nil
+}
+
+func (u *OverlayFs) Mkdir(name string, perm os.FileMode) error {
+	if isWhiteout(name) {
+		return &os.PathError{Op: "mkdir", Path: name, Err: syscall.EINVAL}
+	}
+	if _, err := u.Stat(name); err == nil {
+		return &os.PathError{Op: "mkdir", Path: name, Err: syscall.EEXIST}
+	}
+	fi, err := u.Stat(filepath.Dir(name))
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() {
+		return &os.PathError{Op: "mkdir", Path: name, Err: syscall.ENOTDIR}
+	}
+	if err := u.layer.MkdirAll(filepath.Dir(name), 0777); err != nil {
+		return err
+	}
+	if err := u.layer.Mkdir(name, perm); err != nil {
+		return err
+	}
+	return u.cover(name, true)
+}
+
+func (u *OverlayFs) Name() string {
+	return "OverlayFs"
+}
+
+func (u *OverlayFs) MkdirAll(name string, perm os.FileMode) error {
+	name = filepath.Clean(name)
+	if fi, err := u.Stat(name); err == nil {
+		if fi.IsDir() {
+			return nil
+		}
+		return &os.PathError{Op: "mkdir", Path: name, Err: syscall.ENOTDIR}
+	}
+	if parent := filepath.Dir(name); parent != name {
+		if err := u.MkdirAll(parent, perm); err != nil {
+			return err
+		}
+	}
+	return u.Mkdir(name, perm)
+}
+
+func (u *OverlayFs) Create(name string) (File, error) {
+	return u.OpenFile(name, os.O_CREATE|os.O_TRUNC|os.O_RDWR, 0666)
+}
@@ -0,0 +1,197 @@
+package afero
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func newOverlayTestFs(t *testing.T) (base, layer, ofs Fs) {
+	base = NewMemMapFs()
+	layer = NewMemMapFs()
+	for name, content := range map[string]string{
+		"/data/a.txt":     "a",
+		"/data/b.txt":     "b",
+		"/data/sub/c.txt": "c",
+		"/etc/conf":       "conf",
+	} {
+		if err := WriteFile(base, name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return base, layer, NewOverlayFs(NewReadOnlyFs(base), layer)
+}
+
+func overlayNames(t *testing.T, fs Fs, dir string) []string {
+	f, err := fs.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	names, err := f.Readdirnames(-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func checkNames(t *testing.T, fs Fs, dir string, want ...string) {
+	got := overlayNames(t, fs, dir)
+	if len(got) != len(want) {
+		t.Errorf("%s: got %v, want %v", dir, got, want)
+		return
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("%s: got %v, want %v", dir, got, want)
+			return
+		}
+	}
+}
+
+func checkNotExist(t *testing.T, fs Fs, name string) {
+	if _, err := fs.Stat(name); !os.IsNotExist(err) {
+		t.Errorf("Stat %s: expected a not exist error, got %v", name, err)
+	}
+	if _, err := fs.Open(name); !os.IsNotExist(err) {
+		t.Errorf("Open %s: expected a not exist error, got %v", name, err)
+	}
+}
+
+func TestOverlayRemove(t *testing.T) {
+	base, layer, ofs := newOverlayTestFs(t)
+
+	if err := ofs.Remove("/data/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	checkNotExist(t, ofs, "/data/a.txt")
+	checkNames(t, ofs, "/data", "b.txt", "sub")
+	if ok, _ := Exists(base, "/data/a.txt"); !ok {
+		t.Error("the base file was removed")
+	}
+	if ok, _ := Exists(layer, "/data/"+WhiteoutPrefix+"a.txt"); !ok {
+		t.Error("no whiteout in the overlay")
+	}
+	checkNotExist(t, ofs, "/data/"+WhiteoutPrefix+"a.txt")
+
+	if err := ofs.Remove("/data/sub"); err == nil {
+		t.Error("removed a directory which is not empty")
+	}
+	if err := ofs.RemoveAll("/data/sub"); err != nil {
+		t.Fatal(err)
+	}
+	checkNotExist(t, ofs, "/data/sub")
+	checkNotExist(t, ofs, "/data/sub/c.txt")
+	checkNames(t, ofs, "/data", "b.txt")
+
+	// writing the removed file again replaces the whiteout
+	if err := WriteFile(ofs, "/data/a.txt", []byte("new"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := ReadFile(ofs, "/data/a.txt"); err != nil || string(b) != "new" {
+		t.Errorf("got %q, %v", b, err)
+	}
+	checkNames(t, ofs, "/data", "a.txt", "b.txt")
+}
+
+func TestOverlayOpaqueDir(t *testing.T) {
+	_, layer, ofs := newOverlayTestFs(t)
+
+	if err := ofs.RemoveAll("/data/sub"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ofs.MkdirAll("/data/sub/new", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := Exists(layer, "/data/sub/"+WhiteoutOpaqueDir); !ok {
+		t.Error("the new directory is not opaque")
+	}
+	checkNames(t, ofs, "/data/sub", "new")
+	checkNotExist(t, ofs, "/data/sub/c.txt")
+	checkNames(t, ofs, "/data", "a.txt", "b.txt", "sub")
+}
+
+func TestOverlayRename(t *testing.T) {
+	base, _, ofs := newOverlayTestFs(t)
+
+	if err := ofs.Rename("/data/a.txt", "/moved/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	checkNotExist(t, ofs, "/data/a.txt")
+	if b, err := ReadFile(ofs, "/moved/a.txt"); err != nil || string(b) != "a" {
+		t.Errorf("got %q, %v", b, err)
+	}
+	checkNames(t, ofs, "/data", "b.txt", "sub")
+	checkNames(t, ofs, "/", "data", "etc", "moved")
+
+	// renaming back drops the whiteout
+	if err := ofs.Rename("/moved/a.txt", "/data/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	checkNames(t, ofs, "/data", "a.txt", "b.txt", "sub")
+	checkNames(t, ofs, "/moved")
+
+	err := ofs.Rename("/data/sub", "/data/other")
+	if lerr, ok := err.(*os.LinkError); !ok || lerr.Err != syscall.EXDEV {
+		t.Errorf("expected EXDEV renaming a base directory, got %v", err)
+	}
+	if ok, _ := Exists(base, "/moved"); ok {
+		t.Error("the base was changed")
+	}
+}
+
+func TestOverlayChangeBaseDir(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "afero-overlay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	for _, test := range []struct {
+		name        string
+		base, layer Fs
+	}{
+		{"MemMapFs", NewMemMapFs(), NewMemMapFs()},
+		{"OsFs", NewBasePathFs(NewOsFs(), filepath.Join(tmp, "base")), NewBasePathFs(NewOsFs(), filepath.Join(tmp, "layer"))},
+	} {
+		if err := test.base.MkdirAll("/data/sub", 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := WriteFile(test.base, "/data/sub/c.txt", []byte("c"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		ofs := NewOverlayFs(NewReadOnlyFs(test.base), test.layer)
+
+		mtime := time.Date(2017, 1, 2, 3, 4, 5, 0, time.UTC)
+		if err := ofs.Chtimes("/data/sub", mtime, mtime); err != nil {
+			t.Fatalf("%s: Chtimes: %v", test.name, err)
+		}
+		fi, err := ofs.Stat("/data/sub")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !fi.IsDir() || !fi.ModTime().Equal(mtime) {
+			t.Errorf("%s: got a mode of %v and a time of %v after Chtimes", test.name, fi.Mode(), fi.ModTime())
+		}
+		checkNames(t, ofs, "/data/sub", "c.txt")
+
+		if err := ofs.Chmod("/data", os.ModeDir|0700); err != nil {
+			t.Fatalf("%s: Chmod: %v", test.name, err)
+		}
+		if fi, err = ofs.Stat("/data"); err != nil {
+			t.Fatal(err)
+		}
+		if !fi.IsDir() || fi.Mode().Perm() != 0700 {
+			t.Errorf("%s: got a mode of %v after Chmod", test.name, fi.Mode())
+		}
+		checkNames(t, ofs, "/data", "sub")
+		if fi, err = test.base.Stat("/data"); err != nil || fi.Mode().Perm() == 0700 {
+			t.Errorf("%s: the base was changed", test.name)
+		}
+	}
+}
@@ -0,0 +1,363 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !plan9
+
+package fsnotify
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultPollInterval is the interval of the PollingWatcher created with
+// an interval of 0.
+const DefaultPollInterval = time.Second
+
+// PollingWatcher watches a set of files by polling them, delivering events
+// to a channel like the Watcher does. It works where the notifications of
+// the kernel do not, such as on network filesystems and in some containers.
+//
+// The files are compared by modification time, size, mode and identity
+// (the inode on Unix) between two polls, so the changes made in between
+// are merged: a file created and then written is reported with a single
+// Create event, and a file created and then removed is not reported.
+type PollingWatcher struct {
+	Events   chan Event
+	Errors   chan error
+	interval time.Duration
+	mu       sync.Mutex            // Map access
+	watches  map[string]*pollWatch // Map of watched paths (key: path)
+	trees    trees                 // Trees watched with AddRecursive
+	done     chan struct{}         // Channel for sending a "quit message" to the reader goroutine
+	doneResp chan struct{}         // Channel to respond to Close
+}
+
+// NewPollingWatcher creates a watcher polling its files every interval.
+func NewPollingWatcher(interval time.Duration) (*PollingWatcher, error) {
+	if interval < 0 {
+		return nil, fmt.Errorf("negative polling interval: %v", interval)
+	}
+	if interval == 0 {
+		interval = DefaultPollInterval
+	}
+	w := &PollingWatcher{
+		Events:   make(chan Event),
+		Errors:   make(chan error),
+		interval: interval,
+		watches:  make(map[string]*pollWatch),
+		done:     make(chan struct{}),
+		doneResp: make(chan struct{}),
+	}
+	go w.readEvents()
+	return w, nil
+}
+
+func (w *PollingWatcher) isClosed() bool {
+	select {
+	case <-w.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close removes all watches and closes the events channel.
+func (w *PollingWatcher) Close() error {
+	if w.isClosed() {
+		return nil
+	}
+	close(w.done)
+	<-w.doneResp
+	return nil
+}
+
+// Add starts watching the named file or directory (non-recursively).
+func (w *PollingWatcher) Add(name string) error {
+	name = filepath.Clean(name)
+	if w.isClosed() {
+		return errors.New("polling watcher already closed")
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, found := w.watches[name]; found {
+		return nil
+	}
+	watch, err := scan(name)
+	if err != nil {
+		return err
+	}
+	w.watches[name] = watch
+	return nil
+}
+
+// AddRecursive starts watching the directory tree at root, adding the
+// watches of its directories as they are created, and reports the changes
+// of the files and directories selected by the options. A tree already
+// watched must be removed before it is added again.
+func (w *PollingWatcher) AddRecursive(root string, opts RecursiveOptions) error {
+	if w.isClosed() {
+		return errors.New("polling watcher already closed")
+	}
+	return w.trees.add(filepath.Clean(root), opts, w.Add, w.remove)
+}
+
+// Remove stops watching the named file or directory (non-recursively), or
+// the tree it is the root of if it is watched with AddRecursive.
+func (w *PollingWatcher) Remove(name string) error {
+	name = filepath.Clean(name)
+	if ok, err := w.trees.remove(name, w.remove); ok {
+		return err
+	}
+	return w.remove(name)
+}
+
+func (w *PollingWatcher) remove(name string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.watches[name]; !ok {
+		return fmt.Errorf("can't remove non-existent polling watch for: %s", name)
+	}
+	delete(w.watches, name)
+	return nil
+}
+
+// pollWatch is the state of a watched path at the last poll.
+type pollWatch struct {
+	info  os.FileInfo
+	files map[string]os.FileInfo // Files of a directory (key: name)
+}
+
+// scan reads the state of the watched path name.
+func scan(name string) (*pollWatch, error) {
+	info, err := os.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	watch := &pollWatch{info: info}
+	if info.IsDir() {
+		if watch.files, err = readDir(name); err != nil {
+			return nil, err
+		}
+	}
+	return watch, nil
+}
+
+// readDir returns the files of the directory name. The directory is read
+// again when one of its files is gone before it is found, so that a file
+// renamed meanwhile is not taken for removed.
+func readDir(name string) (map[string]os.FileInfo, error) {
+	for try := 0; ; try++ {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		names, err := f.Readdirnames(-1)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		files := make(map[string]os.FileInfo, len(names))
+		changed := false
+		for _, base := range names {
+			fi, err := os.Lstat(filepath.Join(name, base))
+			if os.IsNotExist(err) {
+				changed = true
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			files[base] = fi
+		}
+		if !changed || try == 2 {
+			return files, nil
+		}
+	}
+}
+
+// readEvents polls the watched paths every interval, and sends the
+// changes found via the Events channel.
+func (w *PollingWatcher) readEvents() {
+	ticker := time.NewTicker(w.interval)
+
+	defer close(w.doneResp)
+	defer close(w.Errors)
+	defer close(w.Events)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-w.done:
+			return
+		}
+
+		events, errs := w.poll()
+		for _, err := range errs {
+			select {
+			case w.Errors <- err:
+			case <-w.done:
+				return
+			}
+		}
+		for _, event := range events {
+			events, errs := w.trees.event(event, w.Add, w.remove)
+			for _, err := range errs {
+				select {
+				case w.Errors <- err:
+				case <-w.done:
+					return
+				}
+			}
+			for _, event := range events {
+				select {
+				case w.Events <- event:
+				case <-w.done:
+					return
+				}
+			}
+		}
+	}
+}
+
+// poll rescans the watched paths, and returns the changes found since the
+// last poll. The events are sent by the caller, so that Add and Remove
+// can be called while they are.
+func (w *PollingWatcher) poll() (events []Event, errs []error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	names := make([]string, 0, len(w.watches))
+	for name := range w.watches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		old := w.watches[name]
+		// The changes of a path are reported once, by the watch of its
+		// directory if there is one.
+		_, inDir := w.watches[filepath.Dir(name)]
+		inDir = inDir && filepath.Dir(name) != name
+
+		watch, err := scan(name)
+		if os.IsNotExist(err) {
+			// The watch goes with the path, as with inotify, which reports
+			// the files of a directory removed as well.
+			delete(w.watches, name)
+			if !inDir {
+				events = append(events, Event{Name: name, Op: Remove})
+			}
+			events = append(events, diffDir(name, old.files, nil)...)
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		w.watches[name] = watch
+		if !inDir {
+			events = append(events, diffFile(name, old.info, watch.info)...)
+		}
+		if old.files != nil && watch.files != nil {
+			events = append(events, diffDir(name, old.files, watch.files)...)
+		}
+	}
+	return events, errs
+}
+
+// diffFile returns the events of a file which was old at the last poll and
+// is info now.
+func diffFile(name string, old, info os.FileInfo) []Event {
+	if !os.SameFile(old, info) {
+		return []Event{{Name: name, Op: Remove}, {Name: name, Op: Create}}
+	}
+	var op Op
+	// The modification time of a directory changes with its files, which
+	// are reported on their own.
+	if !info.IsDir() && (info.Size() != old.Size() || !info.ModTime().Equal(old.ModTime())) {
+		op |= Write
+	}
+	if info.Mode() != old.Mode() {
+		op |= Chmod
+	}
+	if op == 0 {
+		return nil
+	}
+	return []Event{{Name: name, Op: op}}
+}
+
+// diffDir returns the events of the files of the directory name, which
+// were old at the last poll and are files now. A file which is gone, while
+// the same file is found under another name, is reported as renamed, and
+// the file it is found as is reported as created, as with inotify.
+func diffDir(name string, old, files map[string]os.FileInfo) []Event {
+	var removed, created, kept []string
+	for base, info := range old {
+		if fi, ok := files[base]; !ok {
+			removed = append(removed, base)
+		} else if os.SameFile(info, fi) {
+			kept = append(kept, base)
+		} else {
+			// replaced, as by renaming another file over it
+			removed = append(removed, base)
+			created = append(created, base)
+		}
+	}
+	for base := range files {
+		if _, ok := old[base]; !ok {
+			created = append(created, base)
+		}
+	}
+	sort.Strings(removed)
+	sort.Strings(created)
+	sort.Strings(kept)
+
+	// Pair the files which are gone with the files they are found as.
+	renamed := make(map[string]string)
+	targets := make(map[string]bool)
+	for _, base := range removed {
+		if _, ok := files[base]; ok {
+			continue
+		}
+		for _, newBase := range created {
+			if !targets[newBase] && os.SameFile(old[base], files[newBase]) {
+				renamed[base] = newBase
+				targets[newBase] = true
+				break
+			}
+		}
+	}
+
+	// The Create event of a renamed file follows its Rename event, as with
+	// inotify.
+	var events []Event
+	for _, base := range removed {
+		switch {
+		case renamed[base] != "":
+			events = append(events,
+				Event{Name: filepath.Join(name, base), Op: Rename},
+				Event{Name: filepath.Join(name, renamed[base]), Op: Create})
+		case !targets[base]:
+			events = append(events, Event{Name: filepath.Join(name, base), Op: Remove})
+		}
+	}
+	for _, base := range created {
+		if !targets[base] {
+			events = append(events, Event{Name: filepath.Join(name, base), Op: Create})
+		}
+	}
+	for _, base := range kept {
+		events = append(events, diffFile(filepath.Join(name, base), old[base], files[base])...)
+	}
+	return events
+}
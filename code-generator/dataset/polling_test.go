@@ -0,0 +1,221 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build linux
+
+package fsnotify
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const testPollInterval = 10 * time.Millisecond
+
+func newPollingWatcher(t *testing.T) *PollingWatcher {
+	w, err := NewPollingWatcher(testPollInterval)
+	if err != nil {
+		t.Fatalf("NewPollingWatcher() failed: %s", err)
+	}
+	return w
+}
+
+// expectEvents waits for the events of the next polls of w, until the
+// number of events wanted are received.
+func expectEvents(t *testing.T, w *PollingWatcher, want ...Event) {
+	var got []Event
+	timeout := time.After(100 * testPollInterval)
+	for len(got) < len(want) {
+		select {
+		case event := <-w.Events:
+			got = append(got, event)
+		case err := <-w.Errors:
+			t.Fatalf("error received: %s", err)
+		case <-timeout:
+			t.Fatalf("timed out waiting for %v, got %v", want, got)
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	select {
+	case event := <-w.Events:
+		t.Fatalf("unexpected event: %v", event)
+	case <-time.After(3 * testPollInterval):
+	}
+}
+
+// createFile creates the file name with its content at once, so that it is
+// not found empty by a poll.
+func createFile(t *testing.T, name, content string) {
+	tmp := tempMkFile(t, filepath.Dir(filepath.Dir(name)))
+	if err := ioutil.WriteFile(tmp, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, name); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// appendFile appends to the file name with a single write.
+func appendFile(t *testing.T, name, content string) {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPollingWatcherDir(t *testing.T) {
+	dir := tempMkdir(t)
+	defer os.RemoveAll(dir)
+
+	w := newPollingWatcher(t)
+	defer w.Close()
+	if err := w.Add(dir); err != nil {
+		t.Fatalf("Add() failed: %s", err)
+	}
+
+	a := filepath.Join(dir, "a")
+	b := filepath.Join(dir, "b")
+	createFile(t, a, "a")
+	expectEvents(t, w, Event{Name: a, Op: Create})
+
+	appendFile(t, a, "a")
+	expectEvents(t, w, Event{Name: a, Op: Write})
+
+	if err := os.Chmod(a, 0644); err != nil {
+		t.Fatal(err)
+	}
+	expectEvents(t, w, Event{Name: a, Op: Chmod})
+
+	if err := os.Rename(a, b); err != nil {
+		t.Fatal(err)
+	}
+	expectEvents(t, w, Event{Name: a, Op: Rename}, Event{Name: b, Op: Create})
+
+	// b is replaced by another file
+	createFile(t, a, "bb")
+	expectEvents(t, w, Event{Name: a, Op: Create})
+	if err := os.Rename(a, b); err != nil {
+		t.Fatal(err)
+	}
+	expectEvents(t, w, Event{Name: a, Op: Rename}, Event{Name: b, Op: Create})
+
+	if err := os.Mkdir(a, 0755); err != nil {
+		t.Fatal(err)
+	}
+	expectEvents(t, w, Event{Name: a, Op: Create})
+	// the files of the subdirectory are not watched
+	if err := ioutil.WriteFile(filepath.Join(a, "c"), []byte("c"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	expectEvents(t, w)
+
+	if err := os.Remove(b); err != nil {
+		t.Fatal(err)
+	}
+	expectEvents(t, w, Event{Name: b, Op: Remove})
+
+	if err := os.RemoveAll(a); err != nil {
+		t.Fatal(err)
+	}
+	expectEvents(t, w, Event{Name: a, Op: Remove})
+
+	if err := os.Remove(dir); err != nil {
+		t.Fatal(err)
+	}
+	expectEvents(t, w, Event{Name: dir, Op: Remove})
+	if err := w.Remove(dir); err == nil {
+		t.Fatal("expected an error removing a watch of a removed directory")
+	}
+}
+
+func TestPollingWatcherFile(t *testing.T) {
+	dir := tempMkdir(t)
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "file")
+	if err := ioutil.WriteFile(name, []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	w := newPollingWatcher(t)
+	defer w.Close()
+	if err := w.Add(name); err != nil {
+		t.Fatalf("Add() failed: %s", err)
+	}
+
+	// the other files of the directory are not watched
+	if err := ioutil.WriteFile(filepath.Join(dir, "other"), []byte("b"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	expectEvents(t, w)
+
+	appendFile(t, name, "b")
+	expectEvents(t, w, Event{Name: name, Op: Write})
+
+	if err := w.Remove(name); err != nil {
+		t.Fatalf("Remove() failed: %s", err)
+	}
+	if err := os.Remove(name); err != nil {
+		t.Fatal(err)
+	}
+	expectEvents(t, w)
+}
+
+func TestPollingWatcherAddMissing(t *testing.T) {
+	w := newPollingWatcher(t)
+	defer w.Close()
+	if err := w.Add("/nonexistent/fsnotify/path"); !os.IsNotExist(err) {
+		t.Fatalf("expected a not exist error, got %v", err)
+	}
+	if _, err := NewPollingWatcher(-time.Second); err == nil {
+		t.Fatal("expected an error for a negative interval")
+	}
+}
+
+func TestPollingWatcherClose(t *testing.T) {
+	dir := tempMkdir(t)
+	defer os.RemoveAll(dir)
+
+	w := newPollingWatcher(t)
+	if err := w.Add(dir); err != nil {
+		t.Fatalf("Add() failed: %s", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "a"), []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// the pending event is dropped
+	time.Sleep(3 * testPollInterval)
+
+	done := make(chan error)
+	go func() { done <- w.Close() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Close() failed: %s", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Close() did not return")
+	}
+	if _, ok := <-w.Events; ok {
+		t.Fatal("Events not closed")
+	}
+	if _, ok := <-w.Errors; ok {
+		t.Fatal("Errors not closed")
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("second Close() failed: %s", err)
+	}
+	if err := w.Add(dir); err == nil {
+		t.Fatal("expected an error adding a watch to a closed watcher")
+	}
+}
@@ -0,0 +1,181 @@
+package afero
+
+import (
+	"io"
+	"os"
+	"sync"
+	"syscall"
+)
+
+// The QuotaFs is a wrapper which runs out of space like a full disk does:
+// the files of the source Fs can grow by the free space of the QuotaFs,
+// and the writes going past it are cut short and fail with ENOSPC.
+// Truncating, removing and overwriting files gives their space back.
+type QuotaFs struct {
+	Fs
+	mu   sync.Mutex
+	free int64
+}
+
+func NewQuotaFs(source Fs, free int64) *QuotaFs {
+	return &QuotaFs{Fs: source, free: free}
+}
+
+// Free returns the number of bytes the files can still grow by.
+func (q *QuotaFs) Free() int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.free
+}
+
+func (q *QuotaFs) Name() string {
+	return "QuotaFs"
+}
+
+// size returns the size of the file at name, or 0 if it is not a file.
+func (q *QuotaFs) size(name string) int64 {
+	fi, err := q.Fs.Stat(name)
+	if err != nil || fi.IsDir() {
+		return 0
+	}
+	return fi.Size()
+}
+
+func (q *QuotaFs) Create(name string) (File, error) {
+	return q.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+}
+
+func (q *QuotaFs) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var size int64
+	if flag&os.O_TRUNC != 0 {
+		size = q.size(name)
+	}
+	f, err := q.Fs.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	q.free += size
+	return &quotaFile{File: f, q: q, name: name, append: flag&os.O_APPEND != 0}, nil
+}
+
+func (q *QuotaFs) Remove(name string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	size := q.size(name)
+	if err := q.Fs.Remove(name); err != nil {
+		return err
+	}
+	q.free += size
+	return nil
+}
+
+func (q *QuotaFs) RemoveAll(path string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var size int64
+	Walk(q.Fs, path, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	if err := q.Fs.RemoveAll(path); err != nil {
+		return err
+	}
+	q.free += size
+	return nil
+}
+
+func (q *QuotaFs) Rename(oldname, newname string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var size int64
+	if oldname != newname {
+		size = q.size(newname)
+	}
+	if err := q.Fs.Rename(oldname, newname); err != nil {
+		return err
+	}
+	q.free += size
+	return nil
+}
+
+type quotaFile struct {
+	File
+	q      *QuotaFs
+	name   string
+	append bool
+}
+
+// write writes p at off, or at the offset of the file if off is negative,
+// with the given write function, as far as the free space allows.
+func (f *quotaFile) write(off int64, p []byte, write func([]byte) (int, error)) (int, error) {
+	f.q.mu.Lock()
+	defer f.q.mu.Unlock()
+
+	fi, err := f.File.Stat()
+	if err != nil {
+		return 0, err
+	}
+	size := fi.Size()
+	switch {
+	case f.append:
+		off = size
+	case off < 0:
+		if off, err = f.File.Seek(0, io.SeekCurrent); err != nil {
+			return 0, err
+		}
+	}
+
+	full := false
+	if allowed := size + f.q.free - off; int64(len(p)) > allowed {
+		if allowed < 0 {
+			allowed = 0
+		}
+		p = p[:allowed]
+		full = true
+	}
+	n, err := write(p)
+	if end := off + int64(n); end > size {
+		f.q.free -= end - size
+	}
+	if err == nil && full {
+		err = &os.PathError{Op: "write", Path: f.name, Err: syscall.ENOSPC}
+	}
+	return n, err
+}
+
+func (f *quotaFile) Write(p []byte) (int, error) {
+	return f.write(-1, p, f.File.Write)
+}
+
+func (f *quotaFile) WriteAt(p []byte, off int64) (int, error) {
+	return f.write(off, p, func(p []byte) (int, error) {
+		return f.File.WriteAt(p, off)
+	})
+}
+
+func (f *quotaFile) WriteString(s string) (int, error) {
+	return f.Write([]byte(s))
+}
+
+func (f *quotaFile) Truncate(size int64) error {
+	f.q.mu.Lock()
+	defer f.q.mu.Unlock()
+
+	fi, err := f.File.Stat()
+	if err != nil {
+		return err
+	}
+	grow := size - fi.Size()
+	if grow > f.q.free {
+		return &os.PathError{Op: "truncate", Path: f.name, Err: syscall.ENOSPC}
+	}
+	if err := f.File.Truncate(size); err != nil {
+		return err
+	}
+	f.q.free -= grow
+	return nil
+}
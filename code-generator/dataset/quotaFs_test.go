@@ -0,0 +1,68 @@
+package afero
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestQuotaFs(t *testing.T) {
+	qfs := NewQuotaFs(NewMemMapFs(), 10)
+
+	f, err := qfs.Create("/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := f.Write([]byte("12345678")); n != 8 || err != nil {
+		t.Errorf("got %d, %v", n, err)
+	}
+	// overwriting takes no space
+	if n, err := f.WriteAt([]byte("ab"), 0); n != 2 || err != nil {
+		t.Errorf("got %d, %v", n, err)
+	}
+	if n, err := f.Write([]byte("9012")); n != 2 || !errors.Is(err, syscall.ENOSPC) {
+		t.Errorf("expected a short write and ENOSPC, got %d, %v", n, err)
+	}
+	if free := qfs.Free(); free != 0 {
+		t.Errorf("expected no free space, got %d", free)
+	}
+	if err := f.Truncate(12); !errors.Is(err, syscall.ENOSPC) {
+		t.Errorf("expected ENOSPC, got %v", err)
+	}
+	if err := f.Truncate(4); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if free := qfs.Free(); free != 6 {
+		t.Errorf("expected 6 bytes free, got %d", free)
+	}
+	if content, err := ReadFile(qfs, "/file"); err != nil || string(content) != "ab34" {
+		t.Errorf("got %q, %v", content, err)
+	}
+
+	if err := WriteFile(qfs, "/other", []byte("123456"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFile(qfs, "/more", []byte("1"), 0644); !errors.Is(err, syscall.ENOSPC) {
+		t.Errorf("expected ENOSPC, got %v", err)
+	}
+	// the file replaced by the rename gives its space back
+	if err := qfs.Rename("/other", "/file"); err != nil {
+		t.Fatal(err)
+	}
+	if free := qfs.Free(); free != 4 {
+		t.Errorf("expected 4 bytes free, got %d", free)
+	}
+	f, err = qfs.OpenFile("/file", os.O_WRONLY|os.O_TRUNC, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if err := qfs.RemoveAll("/"); err != nil {
+		t.Fatal(err)
+	}
+	if free := qfs.Free(); free != 10 {
+		t.Errorf("expected 10 bytes free, got %d", free)
+	}
+}
@@ -0,0 +1,296 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !plan9
+
+package fsnotify
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// RecursiveOptions are the options of AddRecursive.
+type RecursiveOptions struct {
+	// Patterns select the files and directories under the root which are
+	// reported, by their path relative to the root with slashes as
+	// separators. The patterns are those of path.Match, where "**" also
+	// matches any number of directories, and the patterns starting with
+	// "!" exclude the paths they match: a path is reported if it matches
+	// any of the other patterns, or if there are none, and none of the
+	// excluding ones. The excluded directories are not watched.
+	//
+	// For example, "**/*.go" and "!vendor/**" report the Go files of the
+	// tree, outside of its vendor directory.
+	Patterns []string
+}
+
+// tree is a directory tree watched with AddRecursive.
+type tree struct {
+	root    string
+	include [][]string      // Patterns split into their elements
+	exclude [][]string      // Patterns starting with "!", without it
+	dirs    map[string]bool // Watched directories (key: path)
+}
+
+func newTree(root string, opts RecursiveOptions) (*tree, error) {
+	t := &tree{root: root, dirs: make(map[string]bool)}
+	for _, pattern := range opts.Patterns {
+		exclude := strings.HasPrefix(pattern, "!")
+		elems := strings.Split(strings.TrimPrefix(pattern, "!"), "/")
+		for _, elem := range elems {
+			if _, err := path.Match(elem, ""); err != nil {
+				return nil, fmt.Errorf("bad pattern %q: %v", pattern, err)
+			}
+		}
+		if exclude {
+			t.exclude = append(t.exclude, elems)
+		} else {
+			t.include = append(t.include, elems)
+		}
+	}
+	return t, nil
+}
+
+// rel returns the elements of the path of name relative to the root, or
+// false if name is not under the root.
+func (t *tree) rel(name string) ([]string, bool) {
+	return relElems(t.root, name)
+}
+
+// relElems returns the elements of the path of name relative to dir, or
+// false if name is not dir or under it.
+func relElems(dir, name string) ([]string, bool) {
+	if name == dir {
+		return nil, true
+	}
+	prefix := dir
+	if !strings.HasSuffix(prefix, string(filepath.Separator)) {
+		prefix += string(filepath.Separator)
+	}
+	if !strings.HasPrefix(name, prefix) {
+		return nil, false
+	}
+	return strings.Split(filepath.ToSlash(name[len(prefix):]), "/"), true
+}
+
+func (t *tree) excluded(elems []string) bool {
+	for _, pattern := range t.exclude {
+		if matchElems(pattern, elems) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *tree) included(elems []string) bool {
+	if t.excluded(elems) {
+		return false
+	}
+	for _, pattern := range t.include {
+		if matchElems(pattern, elems) {
+			return true
+		}
+	}
+	return len(t.include) == 0
+}
+
+// matchElems reports whether the path elements match the pattern elements.
+func matchElems(pattern, elems []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(elems); i++ {
+				if matchElems(pattern[1:], elems[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(elems) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], elems[0]); !ok {
+			return false
+		}
+		pattern, elems = pattern[1:], elems[1:]
+	}
+	return len(elems) == 0
+}
+
+// walk watches the directories of the tree under dir with add, and
+// returns them, along with the Create events of what is found there if
+// created is set.
+func (t *tree) walk(dir string, add func(string) error, created bool) (dirs []string, events []Event, errs []error) {
+	filepath.Walk(dir, func(name string, info os.FileInfo, err error) error {
+		if err != nil {
+			if !os.IsNotExist(err) {
+				errs = append(errs, err)
+			}
+			return nil
+		}
+		elems, _ := t.rel(name)
+		if info.IsDir() {
+			if t.excluded(elems) {
+				return filepath.SkipDir
+			}
+			if err := add(name); err != nil {
+				if !os.IsNotExist(err) {
+					errs = append(errs, err)
+				}
+				return filepath.SkipDir
+			}
+			dirs = append(dirs, name)
+		}
+		if created && name != dir && t.included(elems) {
+			events = append(events, Event{Name: name, Op: Create})
+		}
+		return nil
+	})
+	return dirs, events, errs
+}
+
+// trees are the trees of a watcher. Their methods are given the functions
+// adding and removing the watches of the watcher which can be called where
+// they are: event is called by the goroutine sending the events, and the
+// others by any other.
+type trees struct {
+	mu    sync.Mutex
+	trees map[string]*tree // Map of watched trees (key: root)
+}
+
+// add watches the tree at root with add. A root already watched must be
+// removed before it is added again.
+func (ts *trees) add(root string, opts RecursiveOptions, add, remove func(string) error) error {
+	t, err := newTree(root, opts)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("can't watch recursively, not a directory: %s", root)
+	}
+
+	ts.mu.Lock()
+	if _, found := ts.trees[root]; found {
+		ts.mu.Unlock()
+		return fmt.Errorf("already watching recursively: %s", root)
+	}
+	if ts.trees == nil {
+		ts.trees = make(map[string]*tree)
+	}
+	ts.trees[root] = t
+	ts.mu.Unlock()
+
+	// The lock is not held while adding the watches, as the goroutine
+	// sending the events may be adding the new directories meanwhile.
+	dirs, _, errs := t.walk(root, add, false)
+
+	ts.mu.Lock()
+	for _, dir := range dirs {
+		t.dirs[dir] = true
+	}
+	ts.mu.Unlock()
+	if len(errs) > 0 {
+		ts.remove(root, remove)
+		return errs[0]
+	}
+	return nil
+}
+
+// remove removes the watches of the tree at root with remove. It reports
+// whether root is the root of a tree, along with the error removing its
+// watch.
+func (ts *trees) remove(root string, remove func(string) error) (bool, error) {
+	ts.mu.Lock()
+	t, ok := ts.trees[root]
+	if !ok {
+		ts.mu.Unlock()
+		return false, nil
+	}
+	delete(ts.trees, root)
+	dirs := make([]string, 0, len(t.dirs))
+	for dir := range t.dirs {
+		dirs = append(dirs, dir)
+	}
+	ts.mu.Unlock()
+
+	var err error
+	for _, dir := range dirs {
+		if rerr := remove(dir); dir == root {
+			err = rerr
+		}
+	}
+	return true, err
+}
+
+// event returns the events to send for e, once the watches of the trees
+// are updated with add and remove: the directories created under a root
+// are watched, along with what they hold, which is reported as created.
+// The lock is not held while updating the watches, which may send events.
+func (ts *trees) event(e Event, add, remove func(string) error) ([]Event, []error) {
+	ts.mu.Lock()
+	// The innermost tree holding the file handles it.
+	var t *tree
+	var elems []string
+	for _, tr := range ts.trees {
+		if rel, ok := tr.rel(e.Name); ok && (t == nil || len(tr.root) > len(t.root)) {
+			t, elems = tr, rel
+		}
+	}
+	if t == nil {
+		ts.mu.Unlock()
+		return []Event{e}, nil
+	}
+	var removed []string
+	if e.Op&(Remove|Rename) != 0 {
+		for dir := range t.dirs {
+			if _, ok := relElems(e.Name, dir); ok {
+				removed = append(removed, dir)
+				delete(t.dirs, dir)
+			}
+		}
+		if e.Name == t.root {
+			delete(ts.trees, t.root)
+		}
+	}
+	ts.mu.Unlock()
+
+	sort.Strings(removed)
+	for _, dir := range removed {
+		remove(dir)
+	}
+	if e.Name == t.root {
+		return []Event{e}, nil
+	}
+	if t.excluded(elems) {
+		return nil, nil
+	}
+
+	var events []Event
+	if t.included(elems) {
+		events = append(events, e)
+	}
+	if e.Op&Create == 0 {
+		return events, nil
+	}
+	info, err := os.Lstat(e.Name)
+	if err != nil || !info.IsDir() {
+		return events, nil
+	}
+	dirs, created, errs := t.walk(e.Name, add, true)
+	ts.mu.Lock()
+	for _, dir := range dirs {
+		t.dirs[dir] = true
+	}
+	ts.mu.Unlock()
+	return append(events, created...), errs
+}
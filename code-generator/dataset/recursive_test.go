@@ -0,0 +1,108 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build linux
+
+package fsnotify
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMatchElems(t *testing.T) {
+	for _, test := range []struct {
+		pattern, name string
+		match         bool
+	}{
+		{"*.go", "a.go", true},
+		{"*.go", "dir/a.go", false},
+		{"**/*.go", "a.go", true},
+		{"**/*.go", "dir/sub/a.go", true},
+		{"**/*.go", "dir/a.txt", false},
+		{"vendor/**", "vendor", true},
+		{"vendor/**", "vendor/pkg/a.go", true},
+		{"vendor/**", "dir/vendor/a.go", false},
+		{"**/testdata/**", "dir/testdata/a.txt", true},
+		{"dir/*/a.go", "dir/sub/a.go", true},
+		{"dir/*/a.go", "dir/a.go", false},
+	} {
+		match := matchElems(strings.Split(test.pattern, "/"), strings.Split(test.name, "/"))
+		if match != test.match {
+			t.Errorf("matchElems(%q, %q) = %v, want %v", test.pattern, test.name, match, test.match)
+		}
+	}
+}
+
+func TestPollingWatcherAddRecursive(t *testing.T) {
+	dir := tempMkdir(t)
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"sub/deep", "vendor/pkg"} {
+		if err := os.MkdirAll(filepath.Join(dir, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"a.go", "b.txt", "sub/deep/c.go", "vendor/pkg/v.go"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w := newPollingWatcher(t)
+	defer w.Close()
+	if err := w.AddRecursive(dir, RecursiveOptions{Patterns: []string{"[a"}}); err == nil {
+		t.Fatal("expected an error for a bad pattern")
+	}
+	opts := RecursiveOptions{Patterns: []string{"**/*.go", "!vendor/**"}}
+	if err := w.AddRecursive(dir, opts); err != nil {
+		t.Fatalf("AddRecursive() failed: %s", err)
+	}
+	if err := w.AddRecursive(dir, opts); err == nil {
+		t.Fatal("expected an error adding a watched tree again")
+	}
+
+	c := filepath.Join(dir, "sub", "deep", "c.go")
+	appendFile(t, c, "x")
+	expectEvents(t, w, Event{Name: c, Op: Write})
+
+	// the files left out by the patterns are not reported
+	appendFile(t, filepath.Join(dir, "b.txt"), "x")
+	appendFile(t, filepath.Join(dir, "vendor", "pkg", "v.go"), "x")
+	expectEvents(t, w)
+
+	// the new directories are watched, and their files reported
+	newDir := tempMkdir(t)
+	defer os.RemoveAll(newDir)
+	if err := os.Mkdir(filepath.Join(newDir, "inner"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(newDir, "inner", "d.go"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	moved := filepath.Join(dir, "sub", "new")
+	if err := os.Rename(newDir, moved); err != nil {
+		t.Fatal(err)
+	}
+	d := filepath.Join(moved, "inner", "d.go")
+	expectEvents(t, w, Event{Name: d, Op: Create})
+	appendFile(t, d, "x")
+	expectEvents(t, w, Event{Name: d, Op: Write})
+
+	if err := os.RemoveAll(filepath.Join(dir, "sub", "deep")); err != nil {
+		t.Fatal(err)
+	}
+	expectEvents(t, w, Event{Name: c, Op: Remove})
+
+	if err := w.Remove(dir); err != nil {
+		t.Fatalf("Remove() failed: %s", err)
+	}
+	appendFile(t, d, "x")
+	expectEvents(t, w)
+	if err := w.Remove(dir); err == nil {
+		t.Fatal("expected an error removing a removed watch")
+	}
+}
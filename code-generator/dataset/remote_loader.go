@@ -0,0 +1,224 @@
+// Disabling building of remote support in cases where golang is 1.0 or 1.1
+// as the encoding libraries are not implemented or supported.
+
+// +build go1.2
+
+package altsrc
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// RemoteLoader loads the input sources given as http or https URLs.
+type RemoteLoader struct {
+	// Client is the client used to fetch the files.
+	Client *http.Client
+
+	// MaxSize is the largest file, in bytes, which is loaded. Zero means
+	// no limit.
+	MaxSize int64
+
+	// Checksums maps URLs to the SHA-256 checksum expected of the files
+	// they serve, given as "sha256:" followed by the hexadecimal digest.
+	// Files which do not match are rejected.
+	Checksums map[string]string
+
+	// CacheDir, when not empty, is the directory holding a copy of the
+	// files last loaded. The copy is revalidated with its ETag and used
+	// in place of the file when the server cannot be reached or fails.
+	CacheDir string
+}
+
+// defaultRemoteLoader is the RemoteLoader used by the input sources
+// created from files, such as with NewYamlSourceFromFile, for the files
+// given as URLs.
+var defaultRemoteLoader = &RemoteLoader{
+	Client:  &http.Client{Timeout: 30 * time.Second},
+	MaxSize: 10 << 20,
+}
+
+// NewRemoteSource creates a new InputSourceContext from the file at the
+// given http or https URL, fetched with loader, in the format the
+// extension of its path names: ".toml", ".yaml", ".yml", ".json", ".hcl"
+// or ".env".
+func NewRemoteSource(loader *RemoteLoader, rawurl string) (InputSourceContext, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("scheme of %s is unsupported", rawurl)
+	}
+	switch ext := strings.ToLower(path.Ext(u.Path)); ext {
+	case ".toml":
+		return newTomlSource(loader, rawurl)
+	case ".yaml", ".yml":
+		return newYamlSource(loader, rawurl)
+	case ".json":
+		return newJSONSource(loader, rawurl)
+	case ".hcl":
+		return newHCLSource(loader, rawurl)
+	case ".env":
+		return newDotenvSource(loader, rawurl)
+	default:
+		return nil, fmt.Errorf("Unable to load input source from '%s': unsupported file extension '%s'", rawurl, ext)
+	}
+}
+
+// Load fetches the file at the given URL.
+func (l *RemoteLoader) Load(url string) ([]byte, error) {
+	cached, etag := l.readCache(url)
+	b, err := l.fetch(url, etag, cached)
+	if err != nil {
+		if _, ok := err.(*remoteError); ok && cached != nil {
+			return cached, nil
+		}
+		return nil, err
+	}
+	return b, nil
+}
+
+// remoteError is returned when the server cannot be reached or fails, in
+// which case the cached copy of the file is used if there is one.
+type remoteError struct {
+	err error
+}
+
+func (e *remoteError) Error() string {
+	return e.err.Error()
+}
+
+func (l *RemoteLoader) fetch(url, etag string, cached []byte) ([]byte, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	client := l.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, &remoteError{err}
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusNotModified && cached != nil:
+		return cached, nil
+	case res.StatusCode >= 500:
+		return nil, &remoteError{fmt.Errorf("unable to load %s: %s", url, res.Status)}
+	case res.StatusCode < 200 || res.StatusCode > 299:
+		return nil, fmt.Errorf("unable to load %s: %s", url, res.Status)
+	}
+
+	if l.MaxSize > 0 && res.ContentLength > l.MaxSize {
+		return nil, fmt.Errorf("unable to load %s: file is larger than %d bytes", url, l.MaxSize)
+	}
+	var body io.Reader = res.Body
+	if l.MaxSize > 0 {
+		body = io.LimitReader(res.Body, l.MaxSize+1)
+	}
+	b, err := ioutil.ReadAll(body)
+	if err != nil {
+		return nil, &remoteError{err}
+	}
+	if l.MaxSize > 0 && int64(len(b)) > l.MaxSize {
+		return nil, fmt.Errorf("unable to load %s: file is larger than %d bytes", url, l.MaxSize)
+	}
+	if err := l.verify(url, b); err != nil {
+		return nil, err
+	}
+	l.writeCache(url, b, res.Header.Get("ETag"))
+	return b, nil
+}
+
+// verify checks b against the checksum expected of the file at url.
+func (l *RemoteLoader) verify(url string, b []byte) error {
+	want, ok := l.Checksums[url]
+	if !ok {
+		return nil
+	}
+	if !strings.HasPrefix(want, "sha256:") {
+		return fmt.Errorf("unsupported checksum %q for %s", want, url)
+	}
+	sum := sha256.Sum256(b)
+	if got := hex.EncodeToString(sum[:]); !strings.EqualFold(got, want[len("sha256:"):]) {
+		return fmt.Errorf("checksum mismatch for %s: got sha256:%s, want %s", url, got, want)
+	}
+	return nil
+}
+
+// cachePath returns the path of the cached copy of the file at url, which
+// is empty when there is no cache.
+func (l *RemoteLoader) cachePath(url string) string {
+	if l.CacheDir == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(l.CacheDir, hex.EncodeToString(sum[:]))
+}
+
+func (l *RemoteLoader) readCache(url string) ([]byte, string) {
+	path := l.cachePath(url)
+	if path == "" {
+		return nil, ""
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, ""
+	}
+	// A cached copy which was since found to not match its checksum is
+	// not used.
+	if l.verify(url, b) != nil {
+		return nil, ""
+	}
+	etag, _ := ioutil.ReadFile(path + ".etag")
+	return b, string(etag)
+}
+
+// writeCache stores a copy of the file at url. Failing to do so does not
+// prevent the file from being loaded.
+func (l *RemoteLoader) writeCache(url string, b []byte, etag string) {
+	path := l.cachePath(url)
+	if path == "" {
+		return
+	}
+	if err := os.MkdirAll(l.CacheDir, 0700); err != nil {
+		return
+	}
+	tmp, err := ioutil.TempFile(l.CacheDir, ".tmp")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(b)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+	if etag != "" {
+		ioutil.WriteFile(path+".etag", []byte(etag), 0600)
+	} else {
+		os.Remove(path + ".etag")
+	}
+}
@@ -0,0 +1,176 @@
+// Disabling building of remote support in cases where golang is 1.0 or 1.1
+// as the encoding libraries are not implemented or supported.
+
+// +build go1.2
+
+package altsrc
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func sha256Sum(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func TestRemoteLoaderLoad(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/app.yaml":
+			w.Write([]byte("test: 15"))
+		case "/big.yaml":
+			w.Write([]byte(strings.Repeat("#", 2000)))
+		case "/missing.yaml":
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	l := &RemoteLoader{Client: server.Client(), MaxSize: 1000}
+	b, err := l.Load(server.URL + "/app.yaml")
+	expect(t, err, nil)
+	expect(t, string(b), "test: 15")
+
+	_, err = l.Load(server.URL + "/missing.yaml")
+	expect(t, err.Error(), "unable to load "+server.URL+"/missing.yaml: 404 Not Found")
+
+	_, err = l.Load(server.URL + "/big.yaml")
+	expect(t, err.Error(), "unable to load "+server.URL+"/big.yaml: file is larger than 1000 bytes")
+
+	l.Checksums = map[string]string{
+		server.URL + "/app.yaml":     sha256Sum("test: 15"),
+		server.URL + "/missing.yaml": sha256Sum("other"),
+	}
+	_, err = l.Load(server.URL + "/app.yaml")
+	expect(t, err, nil)
+	l.Checksums[server.URL+"/app.yaml"] = sha256Sum("test: 16")
+	_, err = l.Load(server.URL + "/app.yaml")
+	if err == nil || !strings.HasPrefix(err.Error(), "checksum mismatch for "+server.URL+"/app.yaml") {
+		t.Errorf("expected a checksum mismatch, got %v", err)
+	}
+}
+
+func TestRemoteLoaderTimeout(t *testing.T) {
+	done := make(chan bool)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer server.Close()
+	defer close(done)
+
+	l := &RemoteLoader{Client: &http.Client{Timeout: 50 * time.Millisecond}}
+	_, err := l.Load(server.URL + "/app.yaml")
+	refute(t, err, nil)
+}
+
+func TestRemoteLoaderCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "altsrc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var requests []string
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Header.Get("If-None-Match"))
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("test: 15"))
+	}))
+	url := server.URL + "/app.yaml"
+
+	l := &RemoteLoader{Client: server.Client(), CacheDir: dir}
+	for i := 0; i < 2; i++ {
+		b, err := l.Load(url)
+		expect(t, err, nil)
+		expect(t, string(b), "test: 15")
+	}
+	expect(t, requests, []string{"", `"v1"`})
+
+	// Server errors fall back to the cached copy, unlike client errors.
+	status = http.StatusServiceUnavailable
+	b, err := l.Load(url)
+	expect(t, err, nil)
+	expect(t, string(b), "test: 15")
+	status = http.StatusForbidden
+	_, err = l.Load(url)
+	refute(t, err, nil)
+
+	// As do servers which cannot be reached.
+	server.Close()
+	b, err = l.Load(url)
+	expect(t, err, nil)
+	expect(t, string(b), "test: 15")
+
+	// Unless the cached copy does not match its checksum.
+	l.Checksums = map[string]string{url: sha256Sum("test: 16")}
+	_, err = l.Load(url)
+	refute(t, err, nil)
+}
+
+func TestYamlSourceFromURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("top:\n  test: 15\n"))
+	}))
+	defer server.Close()
+
+	isc, err := NewYamlSourceFromFile(server.URL + "/app.yaml")
+	expect(t, err, nil)
+	i, err := isc.Int("top.test")
+	expect(t, err, nil)
+	expect(t, i, 15)
+}
+
+func TestRemoteSource(t *testing.T) {
+	files := map[string]string{
+		"/app.toml": "[top]\ntest = 15\n",
+		"/app.yaml": "top:\n  test: 15\n",
+		"/app.yml":  "top:\n  test: 15\n",
+		"/app.json": `{"top": {"test": 15}}`,
+		"/app.hcl":  "top {\n  test = 15\n}\n",
+		"/app.env":  "top.test=15\n",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(files[r.URL.Path]))
+	}))
+	defer server.Close()
+
+	l := &RemoteLoader{Client: server.Client()}
+	for name := range files {
+		isc, err := NewRemoteSource(l, server.URL+name+"?v=1")
+		expect(t, err, nil)
+		i, err := isc.Int("top.test")
+		expect(t, err, nil)
+		expect(t, i, 15)
+	}
+
+	// Each source is loaded with its own loader.
+	url := server.URL + "/app.yaml"
+	strict := &RemoteLoader{Client: server.Client(), Checksums: map[string]string{url: sha256Sum("other")}}
+	_, err := NewRemoteSource(strict, url)
+	refute(t, err, nil)
+	_, err = NewRemoteSource(l, url)
+	expect(t, err, nil)
+
+	_, err = NewRemoteSource(l, server.URL+"/app.ini")
+	expect(t, err.Error(), "Unable to load input source from '"+server.URL+"/app.ini': unsupported file extension '.ini'")
+	_, err = NewRemoteSource(l, "app.yaml")
+	expect(t, err.Error(), "scheme of app.yaml is unsupported")
+}
@@ -0,0 +1,167 @@
+// Disabling building of save support in cases where golang is 1.0 or 1.1
+// as the encoding libraries are not implemented or supported.
+
+// +build go1.2
+
+package altsrc
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/urfave/cli.v1"
+	"gopkg.in/yaml.v2"
+)
+
+// SaveInputSource writes the values the flags of the context ended up
+// with, be they given on the command line, in the environment, in an
+// input source or left to their defaults, to the file at path, in the
+// format its extension names: ".toml", ".yaml", ".yml" or ".json". Only
+// the flags which can be read from an input source are written, and the
+// names holding '.' delimiters are nested, so that the file can be read
+// back with NewTomlSourceFromFile, NewYamlSourceFromFile or
+// NewJSONSourceFromFile.
+func SaveInputSource(context *cli.Context, path string) error {
+	values, err := inputSourceValues(context)
+	if err != nil {
+		return err
+	}
+
+	var b []byte
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".toml":
+		var buf bytes.Buffer
+		err = toml.NewEncoder(&buf).Encode(values)
+		b = buf.Bytes()
+	case ".yaml", ".yml":
+		b, err = yaml.Marshal(values)
+	case ".json":
+		b, err = json.MarshalIndent(values, "", "  ")
+		b = append(b, '\n')
+	default:
+		return fmt.Errorf("Unable to save input source to '%s': unsupported file extension '%s'", path, ext)
+	}
+	if err != nil {
+		return fmt.Errorf("Unable to save input source to '%s': inner error: \n'%v'", path, err.Error())
+	}
+	return writeFileAtomic(path, b)
+}
+
+// inputSourceValues returns the values of the flags of the context which
+// can be read from an input source, keyed as in an input source.
+func inputSourceValues(context *cli.Context) (map[string]interface{}, error) {
+	flags := context.Command.Flags
+	if context.Command.Name == "" {
+		flags = context.App.Flags
+	}
+
+	values := make(map[interface{}]interface{})
+	for _, f := range flags {
+		if _, isType := f.(FlagInputSourceExtension); !isType {
+			continue
+		}
+		name := strings.TrimSpace(strings.Split(f.GetName(), ",")[0])
+		value, ok := flagValue(context.Generic(name))
+		if !ok {
+			continue
+		}
+		if err := setNested(values, name, value); err != nil {
+			return nil, fmt.Errorf("Unable to save flag '%s': %v", name, err)
+		}
+	}
+	return stringKeys(values), nil
+}
+
+// flagValue converts the value of a flag into one written natively by
+// the encoders. Values which have no such counterpart are written in the
+// text form they are given in on the command line.
+func flagValue(value interface{}) (interface{}, bool) {
+	switch v := value.(type) {
+	case nil:
+		return nil, false
+	case *cli.StringSlice:
+		if len(*v) == 0 {
+			return nil, false
+		}
+		return []string(*v), true
+	case *cli.IntSlice:
+		if len(*v) == 0 {
+			return nil, false
+		}
+		return []int(*v), true
+	case *cli.Int64Slice:
+		if len(*v) == 0 {
+			return nil, false
+		}
+		return []int64(*v), true
+	case *Timestamp:
+		if !v.isSet && v.time.IsZero() {
+			return nil, false
+		}
+		return v.String(), true
+	case flag.Getter:
+		switch got := v.Get().(type) {
+		case bool, int, int64, uint, uint64, float64, string:
+			return got, true
+		}
+		return v.String(), true
+	case flag.Value:
+		return v.String(), true
+	}
+	return nil, false
+}
+
+// stringKeys converts the maps of tree into the maps keyed by strings
+// which the encoders take.
+func stringKeys(tree map[interface{}]interface{}) map[string]interface{} {
+	ret := make(map[string]interface{}, len(tree))
+	for key, val := range tree {
+		if child, ok := val.(map[interface{}]interface{}); ok {
+			val = stringKeys(child)
+		}
+		ret[key.(string)] = val
+	}
+	return ret
+}
+
+// writeFileAtomic writes b to the file at path by way of a temporary file
+// in the same directory, so that the file is never left half written. The
+// file keeps its mode, or is made readable by its owner only if it is new,
+// and a symlink at path is followed rather than replaced.
+func writeFileAtomic(path string, b []byte) error {
+	if real, err := filepath.EvalSymlinks(path); err == nil {
+		path = real
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	mode := os.FileMode(0600)
+	if fi, err := os.Stat(path); err == nil {
+		mode = fi.Mode().Perm()
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(b)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), mode)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
@@ -0,0 +1,147 @@
+// Disabling building of save support in cases where golang is 1.0 or 1.1
+// as the encoding libraries are not implemented or supported.
+
+// +build go1.2
+
+package altsrc
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gopkg.in/urfave/cli.v1"
+)
+
+func TestSaveInputSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "altsrc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	os.Setenv("THE_RATE", "1.5")
+	defer os.Setenv("THE_RATE", "")
+
+	app := cli.NewApp()
+	set := flag.NewFlagSet("test", 0)
+	test := []string{"test-cmd", "--top.test", "7", "--hosts", "a", "--hosts", "b", "--timeout", "90s"}
+	set.Parse(test)
+
+	c := cli.NewContext(app, set, nil)
+
+	command := &cli.Command{
+		Name:        "test-cmd",
+		Aliases:     []string{"tc"},
+		Usage:       "this is for testing",
+		Description: "testing",
+		Action: func(c *cli.Context) error {
+			for _, name := range []string{"app.toml", "app.yaml", "app.json"} {
+				expect(t, SaveInputSource(c, filepath.Join(dir, name)), nil)
+			}
+			return SaveInputSource(c, filepath.Join(dir, "app.ini"))
+		},
+		Flags: []cli.Flag{
+			NewIntFlag(cli.IntFlag{Name: "top.test"}),
+			NewStringFlag(cli.StringFlag{Name: "name, n", Value: "web"}),
+			NewFloat64Flag(cli.Float64Flag{Name: "rate", EnvVar: "THE_RATE"}),
+			NewBoolFlag(cli.BoolFlag{Name: "top.debug"}),
+			NewStringSliceFlag(cli.StringSliceFlag{Name: "hosts"}),
+			NewIntSliceFlag(cli.IntSliceFlag{Name: "ports"}),
+			NewDurationFlag(cli.DurationFlag{Name: "timeout"}),
+			NewTimestampFlag(cli.GenericFlag{Name: "since", Value: NewTimestamp("2006-01-02", time.Date(2017, 3, 4, 0, 0, 0, 0, time.UTC))}),
+			cli.StringFlag{Name: "load"}},
+	}
+	err = command.Run(c)
+	expect(t, err.Error(), "Unable to save input source to '"+filepath.Join(dir, "app.ini")+"': unsupported file extension '.ini'")
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, "app.yaml"))
+	expect(t, err, nil)
+	expect(t, string(b), `hosts:
+- a
+- b
+name: web
+rate: 1.5
+since: 2017-03-04
+timeout: 1m30s
+top:
+  debug: false
+  test: 7
+`)
+
+	for _, load := range []struct {
+		name     string
+		fromFlag func(string) func(*cli.Context) (InputSourceContext, error)
+	}{
+		{"app.toml", NewTomlSourceFromFlagFunc},
+		{"app.yaml", NewYamlSourceFromFlagFunc},
+		{"app.json", NewJSONSourceFromFlagFunc},
+	} {
+		set := flag.NewFlagSet("test", 0)
+		set.Parse([]string{"test-cmd", "--load", filepath.Join(dir, load.name)})
+		c := cli.NewContext(app, set, nil)
+		command := &cli.Command{
+			Name: "test-cmd",
+			Action: func(c *cli.Context) error {
+				expect(t, c.Int("top.test"), 7)
+				expect(t, c.String("name"), "web")
+				expect(t, c.Float64("rate"), 1.5)
+				expect(t, c.StringSlice("hosts"), []string{"a", "b"})
+				expect(t, c.Duration("timeout"), 90*time.Second)
+				expect(t, c.Generic("since").(*Timestamp).Value(), time.Date(2017, 3, 4, 0, 0, 0, 0, time.UTC))
+				return nil
+			},
+			Flags: []cli.Flag{
+				NewIntFlag(cli.IntFlag{Name: "top.test"}),
+				NewStringFlag(cli.StringFlag{Name: "name"}),
+				NewFloat64Flag(cli.Float64Flag{Name: "rate"}),
+				NewStringSliceFlag(cli.StringSliceFlag{Name: "hosts"}),
+				NewDurationFlag(cli.DurationFlag{Name: "timeout"}),
+				NewTimestampFlag(cli.GenericFlag{Name: "since", Value: NewTimestamp("2006-01-02", time.Time{})}),
+				cli.StringFlag{Name: "load"}},
+		}
+		command.Before = InitInputSourceWithContext(command.Flags, load.fromFlag("load"))
+		expect(t, command.Run(c), nil)
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir, err := ioutil.TempDir("", "altsrc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	mode := func(path string) os.FileMode {
+		fi, err := os.Stat(path)
+		expect(t, err, nil)
+		return fi.Mode().Perm()
+	}
+
+	path := filepath.Join(dir, "new.yaml")
+	expect(t, writeFileAtomic(path, []byte("a: 1\n")), nil)
+	expect(t, mode(path), os.FileMode(0600))
+
+	path = filepath.Join(dir, "shared.yaml")
+	expect(t, ioutil.WriteFile(path, nil, 0644), nil)
+	expect(t, os.Chmod(path, 0644), nil)
+	expect(t, writeFileAtomic(path, []byte("a: 1\n")), nil)
+	expect(t, mode(path), os.FileMode(0644))
+
+	// the file a symlink points to is written, and the link kept
+	link := filepath.Join(dir, "link.yaml")
+	if err := os.Symlink("new.yaml", link); err != nil {
+		t.Skip("symlinks are not supported:", err)
+	}
+	expect(t, writeFileAtomic(link, []byte("a: 2\n")), nil)
+	fi, err := os.Lstat(link)
+	expect(t, err, nil)
+	expect(t, fi.Mode()&os.ModeSymlink != 0, true)
+	b, err := ioutil.ReadFile(filepath.Join(dir, "new.yaml"))
+	expect(t, err, nil)
+	expect(t, string(b), "a: 2\n")
+	expect(t, mode(link), os.FileMode(0600))
+}
@@ -0,0 +1,50 @@
+package afero
+
+import (
+	"archive/tar"
+	"io"
+	"io/ioutil"
+)
+
+// The TarFs is a read only Fs serving the files of a tar archive, which
+// is read into memory when the TarFs is created. The archives compressed
+// with gzip are read through a gzip.Reader:
+//
+//	gz, err := gzip.NewReader(f)
+//	...
+//	fs, err := afero.NewTarFs(tar.NewReader(gz))
+//
+// Only the regular files and the directories of the archive are served.
+type TarFs struct {
+	*archiveFs
+}
+
+func NewTarFs(r *tar.Reader) (Fs, error) {
+	t := &TarFs{archiveFs: newArchiveFs()}
+	for {
+		hdr, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			t.add(hdr.Name, &archiveEntry{info: hdr.FileInfo()})
+		case tar.TypeReg, tar.TypeRegA:
+			content, err := ioutil.ReadAll(r)
+			if err != nil {
+				return nil, err
+			}
+			t.add(hdr.Name, &archiveEntry{
+				info:    hdr.FileInfo(),
+				content: func() ([]byte, error) { return content, nil },
+			})
+		}
+	}
+	t.sort()
+	return t, nil
+}
+
+func (t *TarFs) Name() string { return "TarFs" }
@@ -8,6 +8,7 @@ package altsrc
 import (
 	"fmt"
 	"reflect"
+	"time"
 
 	"github.com/BurntSushi/toml"
 	"gopkg.in/urfave/cli.v1"
@@ -58,7 +59,17 @@ func unmarshalMap(i interface{}) (ret map[interface{}]interface{}, err error) {
 				return nil, err
 			}
 		case reflect.Array, reflect.Slice:
-			ret[key] = val.([]interface{})
+			if tmp, err := unmarshalSlice(v); err == nil {
+				ret[key] = tmp
+			} else {
+				return nil, err
+			}
+		case reflect.Struct:
+			if t, ok := val.(time.Time); ok {
+				ret[key] = t
+			} else {
+				return nil, fmt.Errorf("Unsupported: type = %#v", v.Kind())
+			}
 		default:
 			return nil, fmt.Errorf("Unsupported: type = %#v", v.Kind())
 		}
@@ -66,6 +77,20 @@ func unmarshalMap(i interface{}) (ret map[interface{}]interface{}, err error) {
 	return ret, nil
 }
 
+// unmarshalSlice converts the elements of a TOML array as unmarshalMap
+// converts the values of a table.
+func unmarshalSlice(v reflect.Value) ([]interface{}, error) {
+	ret := make([]interface{}, v.Len())
+	for i := range ret {
+		tmp, err := unmarshalMap(map[string]interface{}{"": v.Index(i).Interface()})
+		if err != nil {
+			return nil, err
+		}
+		ret[i] = tmp[""]
+	}
+	return ret, nil
+}
+
 func (self *tomlMap) UnmarshalTOML(i interface{}) error {
 	if tmp, err := unmarshalMap(i); err == nil {
 		self.Map = tmp
@@ -81,12 +106,16 @@ type tomlSourceContext struct {
 
 // NewTomlSourceFromFile creates a new TOML InputSourceContext from a filepath.
 func NewTomlSourceFromFile(file string) (InputSourceContext, error) {
+	return newTomlSource(defaultRemoteLoader, file)
+}
+
+func newTomlSource(loader *RemoteLoader, file string) (InputSourceContext, error) {
 	tsc := &tomlSourceContext{FilePath: file}
 	var results tomlMap = tomlMap{}
-	if err := readCommandToml(tsc.FilePath, &results); err != nil {
+	if err := readCommandToml(loader, tsc.FilePath, &results); err != nil {
 		return nil, fmt.Errorf("Unable to load TOML file '%s': inner error: \n'%v'", tsc.FilePath, err.Error())
 	}
-	return &MapInputSource{valueMap: results.Map}, nil
+	return newMapInputSource(tsc.FilePath, results.Map), nil
 }
 
 // NewTomlSourceFromFlagFunc creates a new TOML InputSourceContext from a provided flag name and source context.
@@ -97,8 +126,8 @@ func NewTomlSourceFromFlagFunc(flagFileName string) func(context *cli.Context) (
 	}
 }
 
-func readCommandToml(filePath string, container interface{}) (err error) {
-	b, err := loadDataFrom(filePath)
+func readCommandToml(loader *RemoteLoader, filePath string, container interface{}) (err error) {
+	b, err := loadDataFrom(loader, filePath)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,408 @@
+// Copyright ©2015 The Go Authors
+// Copyright ©2015 Steve Francia <spf@spf13.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package afero
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// The Glob code in this section is adapted from path/filepath/match.go of
+// the Go standard library, to read the directories through an Fs.
+
+func (a Afero) Glob(pattern string) ([]string, error) {
+	return Glob(a.Fs, pattern)
+}
+
+// Glob returns the names of all files matching pattern or nil if there is
+// no matching file. The syntax of patterns is the same as in
+// filepath.Match. The pattern may describe hierarchical names such as
+// /usr/*/bin/ed.
+//
+// Glob ignores file system errors such as I/O errors reading directories.
+// The only possible returned error is filepath.ErrBadPattern, when pattern
+// is malformed.
+func Glob(fs Fs, pattern string) (matches []string, err error) {
+	if !hasMeta(pattern) {
+		// Lstat not supported by all filesystems.
+		if _, err = lstatIfOs(fs, pattern); err != nil {
+			return nil, nil
+		}
+		return []string{pattern}, nil
+	}
+
+	dir, file := filepath.Split(pattern)
+	dir = cleanGlobPath(dir)
+
+	if !hasMeta(dir) {
+		return glob(fs, dir, file, nil)
+	}
+
+	var m []string
+	m, err = Glob(fs, dir)
+	if err != nil {
+		return
+	}
+	for _, d := range m {
+		matches, err = glob(fs, d, file, matches)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// cleanGlobPath prepares path for glob matching.
+func cleanGlobPath(path string) string {
+	switch path {
+	case "":
+		return "."
+	case FilePathSeparator:
+		// do nothing to the path
+		return path
+	default:
+		return path[0 : len(path)-1] // chop off trailing separator
+	}
+}
+
+// glob searches for files matching pattern in the directory dir
+// and appends them to matches. If the directory cannot be
+// opened, it returns the existing matches. New matches are
+// added in lexicographical order.
+func glob(fs Fs, dir, pattern string, matches []string) (m []string, e error) {
+	m = matches
+	fi, err := fs.Stat(dir)
+	if err != nil {
+		return
+	}
+	if !fi.IsDir() {
+		return
+	}
+	names, err := readDirNames(fs, dir)
+	if err != nil {
+		return
+	}
+
+	for _, n := range names {
+		matched, err := filepath.Match(pattern, n)
+		if err != nil {
+			return m, err
+		}
+		if matched {
+			m = append(m, filepath.Join(dir, n))
+		}
+	}
+	return
+}
+
+// hasMeta reports whether path contains any of the magic characters
+// recognized by filepath.Match.
+func hasMeta(path string) bool {
+	return strings.ContainsAny(path, `*?[\`)
+}
+
+func (a Afero) CopyTree(srcPath string, dst Fs, dstPath string) error {
+	return CopyTree(a.Fs, srcPath, dst, dstPath)
+}
+
+// CopyTree copies the file or the directory tree at srcPath in src to
+// dstPath in dst, which may be another Fs. The modes and the modification
+// times of the files and the directories are preserved, and the files
+// already in dst are overwritten. Symbolic links are copied as the files
+// they point to. Within the same Fs, a tree cannot be copied into itself.
+func CopyTree(src Fs, srcPath string, dst Fs, dstPath string) error {
+	if sameFs(src, dst) && within(srcPath, dstPath) {
+		return &os.PathError{Op: "copy", Path: dstPath, Err: syscall.EINVAL}
+	}
+
+	var dirs []string
+	var infos []os.FileInfo
+	err := Walk(src, srcPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(srcPath, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dstPath, rel)
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			if info, err = src.Stat(path); err != nil {
+				return err
+			}
+			if info.IsDir() {
+				// the tree it points to is not walked
+				return &os.PathError{Op: "copy", Path: path, Err: syscall.ELOOP}
+			}
+		}
+		switch {
+		case info.IsDir():
+			// The directories are made writable until their files are
+			// copied, then given their own mode and times.
+			if err := dst.MkdirAll(target, 0777); err != nil {
+				return err
+			}
+			dirs = append(dirs, target)
+			infos = append(infos, info)
+			return nil
+		case info.Mode().IsRegular():
+			return copyFile(src, path, dst, target, info)
+		}
+		return &os.PathError{Op: "copy", Path: path, Err: syscall.EINVAL}
+	})
+	if err != nil {
+		return err
+	}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if err := dst.Chmod(dirs[i], infos[i].Mode()); err != nil {
+			return err
+		}
+		if err := dst.Chtimes(dirs[i], infos[i].ModTime(), infos[i].ModTime()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sameFs reports whether a and b are known to be the same file system.
+func sameFs(a, b Fs) bool {
+	if fs, ok := a.(Afero); ok {
+		a = fs.Fs
+	}
+	if fs, ok := b.(Afero); ok {
+		b = fs.Fs
+	}
+	if _, ok := a.(*OsFs); ok {
+		_, ok = b.(*OsFs)
+		return ok
+	}
+	t := reflect.TypeOf(a)
+	return t == reflect.TypeOf(b) && t.Comparable() && a == b
+}
+
+// within reports whether the path name is root or inside it.
+func within(root, name string) bool {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return false
+	}
+	name, err = filepath.Abs(name)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(root, name)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func copyFile(src Fs, srcPath string, dst Fs, dstPath string, info os.FileInfo) error {
+	sfh, err := src.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer sfh.Close()
+
+	dfh, err := dst.OpenFile(dstPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dfh, sfh)
+	if cerr := dfh.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if err := dst.Chmod(dstPath, info.Mode()); err != nil {
+		return err
+	}
+	return dst.Chtimes(dstPath, info.ModTime(), info.ModTime())
+}
+
+// DiffKind tells how a file differs between two trees.
+type DiffKind int
+
+const (
+	// DiffAdded is a file which is only in the second tree.
+	DiffAdded DiffKind = iota
+	// DiffRemoved is a file which is only in the first tree.
+	DiffRemoved
+	// DiffType is a file which is a directory in only one of the trees.
+	DiffType
+	// DiffContent is a file whose content differs.
+	DiffContent
+	// DiffMode is a file whose mode differs.
+	DiffMode
+	// DiffModTime is a file whose modification time differs.
+	DiffModTime
+)
+
+func (k DiffKind) String() string {
+	switch k {
+	case DiffAdded:
+		return "added"
+	case DiffRemoved:
+		return "removed"
+	case DiffType:
+		return "type"
+	case DiffContent:
+		return "content"
+	case DiffMode:
+		return "mode"
+	case DiffModTime:
+		return "modtime"
+	}
+	return "unknown"
+}
+
+// A TreeDiff is a difference between two trees found by DiffTree.
+type TreeDiff struct {
+	// Path is the name of the file relative to the roots of the trees.
+	Path string
+	Kind DiffKind
+}
+
+func (d TreeDiff) String() string {
+	return d.Kind.String() + " " + d.Path
+}
+
+func (a Afero) DiffTree(aPath string, b Fs, bPath string) ([]TreeDiff, error) {
+	return DiffTree(a.Fs, aPath, b, bPath)
+}
+
+// DiffTree compares the tree at aPath in a with the tree at bPath in b,
+// which may be another Fs, and returns their differences ordered by path.
+// The files of both trees are compared by their type, content, mode and
+// modification time, which is compared to the second as not all
+// filesystems keep it more precisely. Several differences are returned for
+// a file which differs in several ways.
+func DiffTree(a Fs, aPath string, b Fs, bPath string) ([]TreeDiff, error) {
+	ainfos, err := treeInfos(a, aPath)
+	if err != nil {
+		return nil, err
+	}
+	binfos, err := treeInfos(b, bPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for path := range ainfos {
+		paths = append(paths, path)
+	}
+	for path := range binfos {
+		if _, ok := ainfos[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	var diffs []TreeDiff
+	for _, path := range paths {
+		ai, aok := ainfos[path]
+		bi, bok := binfos[path]
+		switch {
+		case !aok:
+			diffs = append(diffs, TreeDiff{path, DiffAdded})
+			continue
+		case !bok:
+			diffs = append(diffs, TreeDiff{path, DiffRemoved})
+			continue
+		case ai.IsDir() != bi.IsDir():
+			diffs = append(diffs, TreeDiff{path, DiffType})
+			continue
+		}
+		if !ai.IsDir() {
+			same, err := sameContent(a, filepath.Join(aPath, path), b, filepath.Join(bPath, path), ai, bi)
+			if err != nil {
+				return nil, err
+			}
+			if !same {
+				diffs = append(diffs, TreeDiff{path, DiffContent})
+			}
+		}
+		if ai.Mode() != bi.Mode() {
+			diffs = append(diffs, TreeDiff{path, DiffMode})
+		}
+		if !ai.ModTime().Truncate(time.Second).Equal(bi.ModTime().Truncate(time.Second)) {
+			diffs = append(diffs, TreeDiff{path, DiffModTime})
+		}
+	}
+	return diffs, nil
+}
+
+// treeInfos returns the os.FileInfo of the files of the tree at root,
+// keyed by their name relative to root.
+func treeInfos(fs Fs, root string) (map[string]os.FileInfo, error) {
+	infos := make(map[string]os.FileInfo)
+	err := Walk(fs, root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		infos[rel] = info
+		return nil
+	})
+	return infos, err
+}
+
+func sameContent(a Fs, aPath string, b Fs, bPath string, ai, bi os.FileInfo) (bool, error) {
+	if ai.Size() != bi.Size() {
+		return false, nil
+	}
+	afh, err := a.Open(aPath)
+	if err != nil {
+		return false, err
+	}
+	defer afh.Close()
+	bfh, err := b.Open(bPath)
+	if err != nil {
+		return false, err
+	}
+	defer bfh.Close()
+
+	abuf := make([]byte, 32*1024)
+	bbuf := make([]byte, 32*1024)
+	for {
+		an, aerr := io.ReadFull(afh, abuf)
+		bn, berr := io.ReadFull(bfh, bbuf)
+		if !bytes.Equal(abuf[:an], bbuf[:bn]) {
+			return false, nil
+		}
+		if aerr == io.EOF || aerr == io.ErrUnexpectedEOF {
+			return berr == io.EOF || berr == io.ErrUnexpectedEOF, nil
+		}
+		if aerr != nil {
+			return false, aerr
+		}
+		if berr != nil {
+			if berr == io.EOF || berr == io.ErrUnexpectedEOF {
+				return false, nil
+			}
+			return false, berr
+		}
+	}
+}
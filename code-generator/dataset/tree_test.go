@@ -0,0 +1,156 @@
+package afero
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func setupTree(t *testing.T, fs Fs, root string) {
+	mtime := time.Date(2017, 3, 4, 5, 6, 7, 0, time.UTC)
+	for name, content := range map[string]string{
+		"a.txt":         "a",
+		"b.go":          "package b",
+		"sub/c.txt":     "c",
+		"sub/deep/d.go": "package d",
+	} {
+		path := filepath.Join(root, name)
+		if err := WriteFile(fs, path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := fs.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := fs.Chmod(filepath.Join(root, "b.go"), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGlob(t *testing.T) {
+	fs := NewMemMapFs()
+	setupTree(t, fs, "/src")
+
+	for _, test := range []struct {
+		pattern string
+		matches []string
+	}{
+		{"/src/*.txt", []string{"/src/a.txt"}},
+		{"/src/*/*.txt", []string{"/src/sub/c.txt"}},
+		{"/src/s*/*/*.go", []string{"/src/sub/deep/d.go"}},
+		{"/src/[ab].*", []string{"/src/a.txt", "/src/b.go"}},
+		{"/src/a.txt", []string{"/src/a.txt"}},
+		{"/src/none.txt", nil},
+		{"/nowhere/*", nil},
+	} {
+		matches, err := Glob(fs, test.pattern)
+		if err != nil {
+			t.Errorf("%s: %v", test.pattern, err)
+			continue
+		}
+		if !reflect.DeepEqual(matches, test.matches) {
+			t.Errorf("%s: got %v, want %v", test.pattern, matches, test.matches)
+		}
+	}
+
+	if _, err := Glob(fs, "/src/[a"); err != filepath.ErrBadPattern {
+		t.Errorf("expected ErrBadPattern, got %v", err)
+	}
+}
+
+func TestCopyTree(t *testing.T) {
+	src := NewMemMapFs()
+	setupTree(t, src, "/src")
+
+	dstBase := NewMemMapFs()
+	dst := NewBasePathFs(dstBase, "/sandbox")
+	if err := CopyTree(src, "/src", dst, "/copy"); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := ReadFile(dstBase, "/sandbox/copy/sub/deep/d.go"); err != nil || string(b) != "package d" {
+		t.Errorf("got %q, %v", b, err)
+	}
+
+	diffs, err := DiffTree(src, "/src", dst, "/copy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != 0 {
+		t.Errorf("expected no differences, got %v", diffs)
+	}
+
+	// the copy is changed through a union with a fresh overlay
+	ufs := NewOverlayFs(dst, NewMemMapFs())
+	if err := WriteFile(ufs, "/copy/a.txt", []byte("changed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFile(ufs, "/copy/sub/new.txt", []byte("new"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ufs.Chmod("/copy/b.go", 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := src.Remove("/src/sub/c.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	diffs, err = DiffTree(src, "/src", ufs, "/copy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, d := range diffs {
+		if d.Path == "." || d.Path == "sub" {
+			// directory times follow their content
+			continue
+		}
+		got = append(got, d.String())
+	}
+	want := []string{
+		"content a.txt",
+		"modtime a.txt",
+		"mode b.go",
+		"added " + filepath.Join("sub", "c.txt"),
+		"added " + filepath.Join("sub", "new.txt"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestCopyTreeInto(t *testing.T) {
+	fs := NewMemMapFs()
+	setupTree(t, fs, "/src")
+	for _, dst := range []string{"/src", "/src/sub/copy", "/src/./sub/../copy"} {
+		if err := CopyTree(fs, "/src", fs, dst); err == nil {
+			t.Errorf("%s: expected an error copying the tree into itself", dst)
+		}
+	}
+	if err := (Afero{fs}).CopyTree("/src", fs, "/src/copy"); err == nil {
+		t.Error("expected an error copying the tree into itself")
+	}
+	if err := CopyTree(fs, "/src", fs, "/srccopy"); err != nil {
+		t.Error(err)
+	}
+	if err := CopyTree(fs, "/src", NewMemMapFs(), "/src/copy"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCopyTreeFile(t *testing.T) {
+	src := NewMemMapFs()
+	setupTree(t, src, "/src")
+	dst := NewMemMapFs()
+	if err := CopyTree(src, "/src/b.go", dst, "/b.go"); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := dst.Stat("/b.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode() != os.FileMode(0600) {
+		t.Errorf("got mode %v", fi.Mode())
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"syscall"
 )
 
@@ -21,10 +23,11 @@ import (
 // successful read in the overlay will move the cursor position in the base layer
 // by the number of bytes read.
 type UnionFile struct {
-	base  File
-	layer File
-	off   int
-	files []os.FileInfo
+	base      File
+	layer     File
+	off       int
+	files     []os.FileInfo
+	whiteouts bool // the layer holds the whiteout markers of an OverlayFs
 }
 
 func (f *UnionFile) Close() error {
@@ -64,7 +67,10 @@ func (f *UnionFile) ReadAt(s []byte, o int64) (int, error) {
 	if f.layer != nil {
 		n, err := f.layer.ReadAt(s, o)
 		if (err == nil || err == io.EOF) && f.base != nil {
-			_, err = f.base.Seek(o+int64(n), os.SEEK_SET)
+			// as in Read, keep an eventual io.EOF
+			if _, seekErr := f.base.Seek(o+int64(n), os.SEEK_SET); seekErr != nil {
+				err = seekErr
+			}
 		}
 		return n, err
 	}
@@ -124,10 +130,17 @@ func (f *UnionFile) Name() string {
 }
 
 // Readdir will weave the two directories together and
-// return a single view of the overlayed directories
+// return a single view of the overlayed directories, sorted by name.
+// It pages through the entries like os.File.Readdir does.
+//
+// For a directory of an OverlayFs, the whiteout markers of the overlay are
+// left out, along with the files of the base they hide: all of them if the
+// overlay directory is opaque.
 func (f *UnionFile) Readdir(c int) (ofi []os.FileInfo, err error) {
-	if f.off == 0 {
+	if f.files == nil {
 		var files = make(map[string]os.FileInfo)
+		var hidden = make(map[string]bool)
+		var opaque bool
 		var rfi []os.FileInfo
 		if f.layer != nil {
 			rfi, err = f.layer.Readdir(-1)
@@ -135,42 +148,59 @@ func (f *UnionFile) Readdir(c int) (ofi []os.FileInfo, err error) {
 				return nil, err
 			}
 			for _, fi := range rfi {
-				files[fi.Name()] = fi
+				switch name := fi.Name(); {
+				case !f.whiteouts:
+					files[name] = fi
+				case name == WhiteoutOpaqueDir:
+					opaque = true
+				case strings.HasPrefix(name, WhiteoutPrefix):
+					hidden[strings.TrimPrefix(name, WhiteoutPrefix)] = true
+				default:
+					files[name] = fi
+				}
 			}
 		}
 
-		if f.base != nil {
+		if f.base != nil && !opaque {
 			rfi, err = f.base.Readdir(-1)
 			if err != nil {
 				return nil, err
 			}
 			for _, fi := range rfi {
-				if _, exists := files[fi.Name()]; !exists {
+				if _, exists := files[fi.Name()]; !exists && !hidden[fi.Name()] {
 					files[fi.Name()] = fi
 				}
 			}
 		}
+		f.files = make([]os.FileInfo, 0, len(files))
 		for _, fi := range files {
 			f.files = append(f.files, fi)
 		}
+		sort.Sort(byName(f.files))
+	}
+
+	rest := f.files[f.off:]
+	if c <= 0 {
+		f.off = len(f.files)
+		return rest, nil
 	}
-	if c == -1 {
-		return f.files[f.off:], nil
+	if len(rest) == 0 {
+		return nil, io.EOF
 	}
-	defer func() { f.off += c }()
-	return f.files[f.off:c], nil
+	if c > len(rest) {
+		c = len(rest)
+	}
+	f.off += c
+	return rest[:c], nil
 }
 
 func (f *UnionFile) Readdirnames(c int) ([]string, error) {
 	rfi, err := f.Readdir(c)
-	if err != nil {
-		return nil, err
-	}
 	var names []string
 	for _, fi := range rfi {
 		names = append(names, fi.Name())
 	}
-	return names, nil
+	return names, err
 }
 
 func (f *UnionFile) Stat() (os.FileInfo, error) {
@@ -225,7 +255,25 @@ func (f *UnionFile) WriteString(s string) (n int, err error) {
 	return 0, BADFD
 }
 
+// copyToLayer copies the base file at name to the layer. A directory is
+// created there with the mode and times of the base directory, and its
+// files are left in the base.
 func copyToLayer(base Fs, layer Fs, name string) error {
+	fi, err := base.Stat(name)
+	if err != nil {
+		return err
+	}
+	if fi.IsDir() {
+		if err := layer.MkdirAll(name, fi.Mode().Perm()); err != nil {
+			return err
+		}
+		// The mode given to MkdirAll is subject to the umask.
+		if err := layer.Chmod(name, fi.Mode()); err != nil {
+			return err
+		}
+		return layer.Chtimes(name, fi.ModTime(), fi.ModTime())
+	}
+
 	bfh, err := base.Open(name)
 	if err != nil {
 		return err
@@ -270,5 +318,9 @@ func copyToLayer(base Fs, layer Fs, name string) error {
 		lfh.Close()
 		return err
 	}
+	if err = layer.Chmod(name, bfi.Mode()); err != nil {
+		layer.Remove(name)
+		return err
+	}
 	return layer.Chtimes(name, bfi.ModTime(), bfi.ModTime())
 }
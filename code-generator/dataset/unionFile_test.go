@@ -0,0 +1,79 @@
+package afero
+
+import (
+	"io"
+	"reflect"
+	"testing"
+)
+
+// The listing of the UnionFile is sorted, and layer files win over base
+// files.
+func TestUnionFileReaddir(t *testing.T) {
+	base := NewMemMapFs()
+	layer := NewMemMapFs()
+	for _, name := range []string{"/dir/e", "/dir/b", "/dir/d"} {
+		WriteFile(base, name, []byte("base"), 0644)
+	}
+	for _, name := range []string{"/dir/c", "/dir/a", "/dir/b"} {
+		WriteFile(layer, name, []byte("layer"), 0644)
+	}
+	names := []string{"a", "b", "c", "d", "e"}
+
+	for _, fs := range []Fs{
+		NewCopyOnWriteFs(base, layer),
+		NewOverlayFs(base, layer),
+	} {
+		f, err := fs.Open("/dir")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for {
+			fis, err := f.Readdir(3)
+			if err == io.EOF {
+				break
+			}
+			for _, fi := range fis {
+				got = append(got, fi.Name())
+				if fi.Name() == "b" && fi.Size() != int64(len("layer")) {
+					t.Errorf("%s: b is not read from the layer", fs.Name())
+				}
+			}
+		}
+		f.Close()
+		if !reflect.DeepEqual(got, names) {
+			t.Errorf("%s: got %v, want %v", fs.Name(), got, names)
+		}
+	}
+}
+
+// Only the UnionFile of an OverlayFs hides the whiteout markers: those of
+// a CopyOnWriteFs list the files named like them.
+func TestUnionFileReaddirWhiteouts(t *testing.T) {
+	base := NewMemMapFs()
+	layer := NewMemMapFs()
+	WriteFile(base, "/dir/a", []byte("base"), 0644)
+	WriteFile(base, "/dir/.wh.b", []byte("base"), 0644)
+	WriteFile(layer, "/dir/.wh.a", []byte("layer"), 0644)
+
+	for _, test := range []struct {
+		fs    Fs
+		names []string
+	}{
+		{NewCopyOnWriteFs(base, layer), []string{".wh.a", ".wh.b", "a"}},
+		{NewOverlayFs(base, layer), []string{".wh.b"}},
+	} {
+		f, err := test.fs.Open("/dir")
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := f.Readdirnames(-1)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, test.names) {
+			t.Errorf("%s: got %v, want %v", test.fs.Name(), got, test.names)
+		}
+	}
+}
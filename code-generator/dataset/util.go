@@ -20,10 +20,10 @@ import (
 	"bytes"
 	"fmt"
 	"io"
-	"log"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"unicode"
 
 	"golang.org/x/text/transform"
@@ -44,10 +44,8 @@ func WriteReader(fs Fs, path string, r io.Reader) (err error) {
 
 	if ospath != "" {
 		err = fs.MkdirAll(ospath, 0777) // rwx, rw, r
-		if err != nil {
-			if err != os.ErrExist {
-				log.Panicln(err)
-			}
+		if err != nil && !os.IsExist(err) {
+			return
 		}
 	}
 
@@ -95,6 +93,155 @@ func SafeWriteReader(fs Fs, path string, r io.Reader) (err error) {
 	return
 }
 
+// Takes a reader and a path and writes the content atomically: it is
+// written and synced to a temporary file in the same directory, which is
+// then renamed to path, so that path holds either its former content or
+// the whole new content, even if the write fails half-way. The file keeps
+// the mode of the file it replaces, new files are given the mode 0644.
+func (a Afero) AtomicWriteReader(path string, r io.Reader) error {
+	return AtomicWriteReader(a.Fs, path, r)
+}
+
+func AtomicWriteReader(fs Fs, path string, r io.Reader) error {
+	return atomicWrite(fs, path, r, 0644, false)
+}
+
+// Same as AtomicWriteReader but fails if the file/directory already exists.
+// The check is race-free: of two concurrent writers, only one succeeds.
+func (a Afero) SafeAtomicWriteReader(path string, r io.Reader) error {
+	return SafeAtomicWriteReader(a.Fs, path, r)
+}
+
+func SafeAtomicWriteReader(fs Fs, path string, r io.Reader) error {
+	return atomicWrite(fs, path, r, 0644, true)
+}
+
+// AtomicWriteFile writes data to a file named by filename, like WriteFile,
+// but atomically as AtomicWriteReader does. New files are given the mode
+// perm.
+func (a Afero) AtomicWriteFile(filename string, data []byte, perm os.FileMode) error {
+	return AtomicWriteFile(a.Fs, filename, data, perm)
+}
+
+func AtomicWriteFile(fs Fs, filename string, data []byte, perm os.FileMode) error {
+	return atomicWrite(fs, filename, bytes.NewReader(data), perm, false)
+}
+
+func atomicWrite(fs Fs, path string, r io.Reader, perm os.FileMode, safe bool) (err error) {
+	dir, name := filepath.Split(path)
+	dir = filepath.FromSlash(dir)
+	if dir == "" {
+		dir = "."
+	} else if err = fs.MkdirAll(dir, 0777); err != nil && !os.IsExist(err) {
+		return
+	}
+
+	if fi, err := fs.Stat(path); err == nil {
+		if safe {
+			return fmt.Errorf("%v already exists", path)
+		}
+		perm = fi.Mode().Perm()
+	}
+
+	tmp, err := TempFile(fs, dir, "."+name+".tmp")
+	if err != nil {
+		return
+	}
+	// The name of the file is not taken as is, as the Fs such as BasePathFs
+	// hand out the files of another Fs.
+	tmpName := filepath.Join(dir, filepath.Base(tmp.Name()))
+	defer func() {
+		if err != nil {
+			fs.Remove(tmpName)
+		}
+	}()
+
+	_, err = io.Copy(tmp, r)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = fs.Chmod(tmpName, perm)
+	}
+	if err == nil && safe {
+		err = claim(fs, tmpName, path)
+	} else if err == nil {
+		err = fs.Rename(tmpName, path)
+	}
+	if err != nil {
+		return
+	}
+
+	// The rename itself is made durable by syncing the directory, where
+	// the Fs supports it.
+	if d, derr := fs.Open(dir); derr == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
+
+// claimMu guards the existence check of claim for the Fs which cannot
+// link files.
+var claimMu sync.Mutex
+
+// claim moves the temporary file tmpName to path, which
+// SafeAtomicWriteReader writes, or fails if the file already exists. The
+// file is hard linked to path where the Fs lives on the disk, which fails
+// if path exists even when another process creates it meanwhile, and
+// renamed under claimMu otherwise. Either way, path never shows an
+// incomplete file.
+func claim(fs Fs, tmpName, path string) error {
+	if oldname, newname, ok := osPaths(fs, tmpName, path); ok {
+		err := os.Link(oldname, newname)
+		if err == nil {
+			fs.Remove(tmpName)
+			return nil
+		}
+		if os.IsExist(err) {
+			return fmt.Errorf("%v already exists", path)
+		}
+		// The filesystems without hard links fall back to the rename.
+	}
+
+	claimMu.Lock()
+	defer claimMu.Unlock()
+
+	exists, err := Exists(fs, path)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("%v already exists", path)
+	}
+	return fs.Rename(tmpName, path)
+}
+
+// osPaths returns the paths on the disk of the files oldname and newname
+// of fs, or false if fs is not an OsFs, possibly under BasePathFs.
+func osPaths(fs Fs, oldname, newname string) (string, string, bool) {
+	for {
+		switch f := fs.(type) {
+		case *OsFs, OsFs:
+			return oldname, newname, true
+		case *BasePathFs:
+			var err error
+			if oldname, err = f.RealPath(oldname); err != nil {
+				return "", "", false
+			}
+			if newname, err = f.RealPath(newname); err != nil {
+				return "", "", false
+			}
+			fs = f.source
+		default:
+			return "", "", false
+		}
+	}
+}
+
 func (a Afero) GetTempDir(subPath string) string {
 	return GetTempDir(a.Fs, subPath)
 }
@@ -18,11 +18,13 @@
 package afero
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -374,6 +376,107 @@ func TestWriteToDisk(t *testing.T) {
 	}
 }
 
+type failingReader struct{}
+
+func (failingReader) Read(p []byte) (int, error) {
+	return 0, errors.New("read failed")
+}
+
+func TestAtomicWrite(t *testing.T) {
+	osDir, err := TempDir(NewOsFs(), "", "afero")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(osDir)
+
+	for _, fs := range []Fs{
+		NewBasePathFs(NewOsFs(), osDir),
+		new(MemMapFs),
+		NewBasePathFs(new(MemMapFs), "/base"),
+	} {
+		name := filepath.Join("/atomic", "file.txt")
+
+		if err := AtomicWriteFile(fs, name, []byte("first"), 0600); err != nil {
+			t.Fatalf("%s: %v", fs.Name(), err)
+		}
+		if err := fs.Chmod(name, 0640); err != nil {
+			t.Fatal(err)
+		}
+		if err := AtomicWriteReader(fs, name, strings.NewReader("second")); err != nil {
+			t.Fatalf("%s: %v", fs.Name(), err)
+		}
+		if err := AtomicWriteReader(fs, name, failingReader{}); err == nil {
+			t.Errorf("%s: expected the read error", fs.Name())
+		}
+		contents, err := ReadFile(fs, name)
+		if err != nil || string(contents) != "second" {
+			t.Errorf("%s: expected contents %q but got %q, %v", fs.Name(), "second", contents, err)
+		}
+		fi, err := fs.Stat(name)
+		if err != nil || fi.Mode().Perm() != 0640 {
+			t.Errorf("%s: expected the mode to be kept, got %v, %v", fs.Name(), fi.Mode(), err)
+		}
+
+		e := SafeAtomicWriteReader(fs, name, strings.NewReader("third"))
+		if e == nil || e.Error() != name+" already exists" {
+			t.Errorf("%s: expected the file to exist, got %v", fs.Name(), e)
+		}
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		var succeeded int
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				if SafeAtomicWriteReader(fs, "/atomic/safe.txt", strings.NewReader(strconv.Itoa(i))) == nil {
+					mu.Lock()
+					succeeded++
+					mu.Unlock()
+				}
+			}(i)
+		}
+		wg.Wait()
+		if succeeded != 1 {
+			t.Errorf("%s: expected a single safe write to succeed, got %d", fs.Name(), succeeded)
+		}
+
+		names, err := readDirNames(fs, "/atomic")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(names) != 2 || names[0] != "file.txt" || names[1] != "safe.txt" {
+			t.Errorf("%s: expected no temporary files to be left, got %v", fs.Name(), names)
+		}
+	}
+}
+
+// A safe write which fails leaves no file behind, so that it can be
+// retried.
+func TestSafeAtomicWriteFailure(t *testing.T) {
+	fs := NewFaultFs(new(MemMapFs), FaultRule{Op: "Rename", Nth: 1})
+	name := filepath.Join("/atomic", "safe.txt")
+
+	if err := SafeAtomicWriteReader(fs, name, strings.NewReader("first")); err == nil {
+		t.Fatal("expected the rename error")
+	}
+	names, err := readDirNames(fs, "/atomic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 0 {
+		t.Errorf("expected no files to be left, got %v", names)
+	}
+
+	if err := SafeAtomicWriteReader(fs, name, strings.NewReader("second")); err != nil {
+		t.Fatalf("expected the write to be retried, got %v", err)
+	}
+	contents, err := ReadFile(fs, name)
+	if err != nil || string(contents) != "second" {
+		t.Errorf("expected contents %q but got %q, %v", "second", contents, err)
+	}
+}
+
 func TestGetTempDir(t *testing.T) {
 	dir := os.TempDir()
 	if FilePathSeparator != dir[len(dir)-1:] {
@@ -0,0 +1,305 @@
+// Disabling building of validation support in cases where golang is 1.0
+// or 1.1 as the encoding libraries are not implemented or supported.
+
+// +build go1.2
+
+package altsrc
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/urfave/cli.v1"
+	"gopkg.in/yaml.v2"
+)
+
+// ConfigProblem is a problem found in a config file by ValidateConfigFile.
+type ConfigProblem struct {
+	File string
+	// Line and Column locate the key, starting at 1. They are 0 when the
+	// position of the key is not known.
+	Line, Column int
+	Key          string
+	Message      string
+}
+
+func (p ConfigProblem) String() string {
+	if p.Line == 0 {
+		return fmt.Sprintf("%s: %s", p.File, p.Message)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", p.File, p.Line, p.Column, p.Message)
+}
+
+// ValidateConfigFile checks the TOML or YAML file at path, as its
+// extension names, against the flags which read their values from input
+// sources. It reports the keys which no flag reads, the values which do
+// not suit the type of their flag, and the keys found in deprecated, which
+// maps deprecated keys to a message such as the key replacing them. The
+// error returned is not nil when the file cannot be loaded at all.
+func ValidateConfigFile(path string, flags []cli.Flag, deprecated map[string]string) ([]ConfigProblem, error) {
+	var (
+		isc       InputSourceContext
+		err       error
+		positions map[string][2]int
+	)
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".toml":
+		isc, err = NewTomlSourceFromFile(path)
+		if err == nil {
+			positions, err = tomlKeyPositions(path)
+		}
+	case ".yaml", ".yml":
+		isc, err = NewYamlSourceFromFile(path)
+		if err == nil {
+			positions, err = yamlKeyPositions(path)
+		}
+	default:
+		return nil, fmt.Errorf("Unable to validate '%s': unsupported file extension '%s'", path, ext)
+	}
+	if err != nil {
+		return nil, err
+	}
+	mis := isc.(*MapInputSource)
+
+	v := &configValidator{
+		mis:        mis,
+		positions:  positions,
+		deprecated: deprecated,
+		known:      make(map[string]FlagInputSourceExtension),
+	}
+	for _, f := range flags {
+		if ext, isType := f.(FlagInputSourceExtension); isType {
+			eachName(f.GetName(), func(name string) {
+				v.known[name] = ext
+			})
+		}
+	}
+	v.walk("", mis.valueMap)
+	for name, f := range v.known {
+		if mis.isSet(name) {
+			v.checkType(name, f)
+		}
+	}
+
+	sort.Sort(byPosition(v.problems))
+	return v.problems, nil
+}
+
+type configValidator struct {
+	mis        *MapInputSource
+	positions  map[string][2]int
+	deprecated map[string]string
+	known      map[string]FlagInputSourceExtension
+	problems   []ConfigProblem
+}
+
+func (v *configValidator) report(key, format string, args ...interface{}) {
+	at := key
+	pos, ok := v.positions[at]
+	// The elements of lists may not have positions of their own.
+	for !ok && strings.HasSuffix(at, "]") {
+		at = at[:strings.LastIndex(at, "[")]
+		pos, ok = v.positions[at]
+	}
+	v.problems = append(v.problems, ConfigProblem{
+		File:    v.mis.file,
+		Line:    pos[0],
+		Column:  pos[1],
+		Key:     key,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// walk reports the unknown and deprecated keys of tree, whose keys are
+// prefixed with prefix.
+func (v *configValidator) walk(prefix string, tree map[interface{}]interface{}) {
+	for key, value := range tree {
+		name := prefix + fmt.Sprint(key)
+		if msg, ok := v.deprecated[name]; ok {
+			v.report(name, "key '%s' is deprecated: %s", name, msg)
+		}
+		if _, ok := v.known[name]; ok {
+			continue
+		}
+		if child, ok := value.(map[interface{}]interface{}); ok && v.isPrefix(name+".") {
+			v.walk(name+".", child)
+			continue
+		}
+		if _, ok := v.deprecated[name]; !ok {
+			v.report(name, "unknown key '%s'", name)
+		}
+	}
+}
+
+func (v *configValidator) isPrefix(prefix string) bool {
+	for name := range v.known {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkType reports the value of the key with the given name when it
+// does not suit the type of f.
+func (v *configValidator) checkType(name string, f FlagInputSourceExtension) {
+	var err error
+	switch f := f.(type) {
+	case *IntFlag:
+		_, err = v.mis.Int(name)
+	case *DurationFlag:
+		_, err = v.mis.Duration(name)
+	case *Float64Flag:
+		_, err = v.mis.Float64(name)
+	case *StringFlag:
+		_, err = v.mis.String(name)
+	case *StringSliceFlag:
+		_, err = v.mis.StringSlice(name)
+	case *IntSliceFlag:
+		_, err = v.mis.IntSlice(name)
+	case *GenericFlag:
+		_, err = v.mis.Generic(name)
+	case *BoolFlag:
+		_, err = v.mis.Bool(name)
+	case *BoolTFlag:
+		_, err = v.mis.BoolT(name)
+	case *TimestampFlag:
+		if ts, isType := f.Value.(*Timestamp); isType {
+			_, err = v.mis.Timestamp(name, ts.layout)
+		}
+	}
+	if e, isType := err.(*typeMismatchError); isType {
+		v.report(e.key, "key '%s' must be %s, not %s", e.key, e.expected, describeValue(e.value))
+	} else if err != nil {
+		v.report(name, "%v", err)
+	}
+}
+
+type byPosition []ConfigProblem
+
+func (p byPosition) Len() int      { return len(p) }
+func (p byPosition) Swap(i, j int) { p[i], p[j] = p[j], p[i] }
+func (p byPosition) Less(i, j int) bool {
+	if p[i].Line != p[j].Line {
+		return p[i].Line < p[j].Line
+	}
+	if p[i].Column != p[j].Column {
+		return p[i].Column < p[j].Column
+	}
+	return p[i].Message < p[j].Message
+}
+
+// yamlKeyPositions returns the line and column of the keys of the YAML
+// file at path, and of the elements of its lists, keyed as in
+// MapInputSource.
+func yamlKeyPositions(path string) (map[string][2]int, error) {
+	b, err := loadDataFrom(defaultRemoteLoader, path)
+	if err != nil {
+		return nil, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	positions := make(map[string][2]int)
+	var walk func(prefix string, n *yaml.Node)
+	walk = func(prefix string, n *yaml.Node) {
+		switch n.Kind {
+		case yaml.DocumentNode:
+			for _, c := range n.Content {
+				walk(prefix, c)
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				k := n.Content[i]
+				name := prefix + k.Value
+				positions[name] = [2]int{k.Line, k.Column}
+				walk(name+".", n.Content[i+1])
+			}
+		case yaml.SequenceNode:
+			name := strings.TrimSuffix(prefix, ".")
+			for i, c := range n.Content {
+				positions[fmt.Sprintf("%s[%d]", name, i)] = [2]int{c.Line, c.Column}
+			}
+		}
+	}
+	walk("", &doc)
+	return positions, nil
+}
+
+// tomlKeyPositions returns the line and column of the keys of the TOML
+// file at path, keyed as in MapInputSource. The file is scanned line by
+// line for table headers and key assignments, which is enough to locate
+// the keys of the files which BurntSushi/toml loaded successfully.
+func tomlKeyPositions(path string) (map[string][2]int, error) {
+	b, err := loadDataFrom(defaultRemoteLoader, path)
+	if err != nil {
+		return nil, err
+	}
+	positions := make(map[string][2]int)
+	prefix := ""
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		trimmed := strings.TrimSpace(text)
+		column := len(text) - len(strings.TrimLeft(text, " \t")) + 1
+		switch {
+		case trimmed == "" || strings.HasPrefix(trimmed, "#"):
+		case strings.HasPrefix(trimmed, "["):
+			name := strings.Trim(trimmed[:strings.LastIndex(trimmed, "]")+1], "[] \t")
+			name = strings.Replace(name, `"`, "", -1)
+			if _, ok := positions[name]; !ok {
+				positions[name] = [2]int{line, column}
+			}
+			prefix = name + "."
+		default:
+			i := strings.Index(trimmed, "=")
+			if i < 1 {
+				continue
+			}
+			name := prefix + strings.Trim(strings.TrimSpace(trimmed[:i]), `"'`)
+			if _, ok := positions[name]; !ok {
+				positions[name] = [2]int{line, column}
+			}
+		}
+	}
+	return positions, scanner.Err()
+}
+
+// NewValidateConfigCommand creates a command checking the TOML or YAML
+// files given as arguments with ValidateConfigFile. The problems found are
+// written to the output of the app, one per line, and the command exits
+// with status 1 when there are any, so that it can be run in CI.
+func NewValidateConfigCommand(flags []cli.Flag, deprecated map[string]string) cli.Command {
+	return cli.Command{
+		Name:      "validate-config",
+		Usage:     "check config files against the flags they set",
+		ArgsUsage: "FILE...",
+		Action: func(context *cli.Context) error {
+			if context.NArg() == 0 {
+				return cli.NewExitError("validate-config: no files given", 2)
+			}
+			count := 0
+			for _, path := range context.Args() {
+				problems, err := ValidateConfigFile(path, flags, deprecated)
+				if err != nil {
+					fmt.Fprintln(context.App.Writer, err)
+					count++
+					continue
+				}
+				for _, p := range problems {
+					fmt.Fprintln(context.App.Writer, p)
+				}
+				count += len(problems)
+			}
+			if count > 0 {
+				return cli.NewExitError(fmt.Sprintf("validate-config: %d problem(s) found", count), 1)
+			}
+			return nil
+		},
+	}
+}
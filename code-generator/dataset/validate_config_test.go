@@ -0,0 +1,122 @@
+// Disabling building of validation support in cases where golang is 1.0
+// or 1.1 as the encoding libraries are not implemented or supported.
+
+// +build go1.2
+
+package altsrc
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"gopkg.in/urfave/cli.v1"
+)
+
+var validateConfigFlags = []cli.Flag{
+	NewIntFlag(cli.IntFlag{Name: "top.test"}),
+	NewStringFlag(cli.StringFlag{Name: "name, n"}),
+	NewStringSliceFlag(cli.StringSliceFlag{Name: "hosts"}),
+	NewDurationFlag(cli.DurationFlag{Name: "timeout"}),
+	NewBoolFlag(cli.BoolFlag{Name: "debug"}),
+	cli.StringFlag{Name: "load"},
+}
+
+var validateConfigDeprecated = map[string]string{
+	"host":      "use hosts instead",
+	"top.retry": "retries are no longer configurable",
+}
+
+func TestValidateConfigFileYaml(t *testing.T) {
+	ioutil.WriteFile("current.yaml", []byte(`name: web
+host: a
+hosts: [a, 1]
+load: other.yaml
+top:
+  test: x
+  retry: 3
+  extra: true
+timeout: 90
+debug: true
+`), 0666)
+	defer os.Remove("current.yaml")
+
+	problems, err := ValidateConfigFile("current.yaml", validateConfigFlags, validateConfigDeprecated)
+	expect(t, err, nil)
+	var lines []string
+	for _, p := range problems {
+		lines = append(lines, p.String())
+	}
+	expect(t, lines, []string{
+		"current.yaml:2:1: key 'host' is deprecated: use hosts instead",
+		"current.yaml:3:12: key 'hosts[1]' must be a string, not int 1",
+		"current.yaml:4:1: unknown key 'load'",
+		"current.yaml:6:3: key 'top.test' must be an integer, not string \"x\"",
+		"current.yaml:7:3: key 'top.retry' is deprecated: retries are no longer configurable",
+		"current.yaml:8:3: unknown key 'top.extra'",
+		"current.yaml:9:1: key 'timeout' must be a duration such as \"1h30m\", not int 90",
+	})
+	expect(t, problems[1].Key, "hosts[1]")
+}
+
+func TestValidateConfigFileToml(t *testing.T) {
+	ioutil.WriteFile("current.toml", []byte(`n = "web"
+timeout = "1m"
+
+[top]
+test = 15
+  other = 1
+
+[servers]
+a = 1
+`), 0666)
+	defer os.Remove("current.toml")
+
+	problems, err := ValidateConfigFile("current.toml", validateConfigFlags, validateConfigDeprecated)
+	expect(t, err, nil)
+	expect(t, problems, []ConfigProblem{
+		{File: "current.toml", Line: 6, Column: 3, Key: "top.other", Message: "unknown key 'top.other'"},
+		{File: "current.toml", Line: 8, Column: 1, Key: "servers", Message: "unknown key 'servers'"},
+	})
+}
+
+func TestValidateConfigCommand(t *testing.T) {
+	ioutil.WriteFile("current.yaml", []byte("name: web\n"), 0666)
+	defer os.Remove("current.yaml")
+	ioutil.WriteFile("invalid.yaml", []byte("debug: 1\n"), 0666)
+	defer os.Remove("invalid.yaml")
+
+	for _, test := range []struct {
+		args   []string
+		output string
+		err    string
+	}{
+		{[]string{"current.yaml"}, "", ""},
+		{[]string{"current.yaml", "invalid.yaml"}, "invalid.yaml:1:1: key 'debug' must be a boolean, not int 1\n", "validate-config: 1 problem(s) found"},
+		{[]string{"missing.yaml"}, "Unable to load Yaml file 'missing.yaml': inner error: \n'Cannot read from file: 'missing.yaml' because it does not exist.'\n", "validate-config: 1 problem(s) found"},
+	} {
+		var buf bytes.Buffer
+		app := cli.NewApp()
+		app.Writer = &buf
+		set := flag.NewFlagSet("test", 0)
+		set.Parse(test.args)
+		c := cli.NewContext(app, set, nil)
+
+		command := NewValidateConfigCommand(validateConfigFlags, validateConfigDeprecated)
+		err := command.Action.(func(*cli.Context) error)(c)
+		expect(t, buf.String(), test.output)
+		if test.err == "" {
+			expect(t, err, nil)
+			continue
+		}
+		exitErr, isType := err.(*cli.ExitError)
+		if !isType {
+			t.Errorf("expected an *cli.ExitError, got %#v", err)
+			continue
+		}
+		expect(t, exitErr.Error(), test.err)
+		expect(t, exitErr.ExitCode(), 1)
+	}
+}
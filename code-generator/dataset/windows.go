@@ -25,6 +25,7 @@ type Watcher struct {
 	mu       sync.Mutex     // Map access
 	port     syscall.Handle // Handle to completion port
 	watches  watchMap       // Map of watches (key: i-number)
+	trees    trees          // Trees watched with AddRecursive
 	input    chan *input    // Inputs to the reader are sent on this channel
 	quit     chan chan<- error
 }
@@ -81,11 +82,31 @@ func (w *Watcher) Add(name string) error {
 	return <-in.reply
 }
 
-// Remove stops watching the the named file or directory (non-recursively).
+// AddRecursive starts watching the directory tree at root, adding the
+// watches of its directories as they are created, and reports the changes
+// of the files and directories selected by the options. A tree already
+// watched must be removed before it is added again.
+func (w *Watcher) AddRecursive(root string, opts RecursiveOptions) error {
+	if w.isClosed {
+		return errors.New("watcher already closed")
+	}
+	return w.trees.add(filepath.Clean(root), opts, w.Add, w.remove)
+}
+
+// Remove stops watching the the named file or directory (non-recursively),
+// or the tree it is the root of if it is watched with AddRecursive.
 func (w *Watcher) Remove(name string) error {
+	name = filepath.Clean(name)
+	if ok, err := w.trees.remove(name, w.remove); ok {
+		return err
+	}
+	return w.remove(name)
+}
+
+func (w *Watcher) remove(name string) error {
 	in := &input{
 		op:    opRemoveWatch,
-		path:  filepath.Clean(name),
+		path:  name,
 		reply: make(chan error),
 	}
 	w.input <- in
@@ -518,15 +539,31 @@ func (w *Watcher) sendEvent(name string, mask uint64) bool {
 	if mask == 0 {
 		return false
 	}
-	event := newEvent(name, uint32(mask))
-	select {
-	case ch := <-w.quit:
-		w.quit <- ch
-	case w.Events <- event:
+	events, errs := w.trees.event(newEvent(name, uint32(mask)), w.addDir, w.remWatch)
+	for _, err := range errs {
+		select {
+		case ch := <-w.quit:
+			w.quit <- ch
+			return true
+		case w.Errors <- err:
+		}
+	}
+	for _, event := range events {
+		select {
+		case ch := <-w.quit:
+			w.quit <- ch
+			return true
+		case w.Events <- event:
+		}
 	}
 	return true
 }
 
+// Must run within the I/O thread.
+func (w *Watcher) addDir(pathname string) error {
+	return w.addWatch(pathname, sysFSALLEVENTS)
+}
+
 func toWindowsFlags(mask uint64) uint32 {
 	var m uint32
 	if mask&sysFSACCESS != 0 {
@@ -0,0 +1,91 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build windows
+
+package fsnotify
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// expectEvent reads the events of w until want, failing on the events of
+// other files. Windows may report a change with several events.
+func expectEvent(t *testing.T, w *Watcher, want Event) {
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case event := <-w.Events:
+			if event == want {
+				return
+			}
+			if event.Name != want.Name {
+				t.Fatalf("unexpected event: %v, waiting for %v", event, want)
+			}
+		case err := <-w.Errors:
+			t.Fatalf("error received: %s", err)
+		case <-timeout:
+			t.Fatalf("timed out waiting for %v", want)
+		}
+	}
+}
+
+func TestWatcherAddRecursive(t *testing.T) {
+	dir := tempMkdir(t)
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"sub/deep", "vendor/pkg"} {
+		if err := os.MkdirAll(filepath.Join(dir, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"a.go", "b.txt", "sub/deep/c.go", "vendor/pkg/v.go"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w := newWatcher(t)
+	defer w.Close()
+	if err := w.AddRecursive(dir, RecursiveOptions{Patterns: []string{"[a"}}); err == nil {
+		t.Fatal("expected an error for a bad pattern")
+	}
+	opts := RecursiveOptions{Patterns: []string{"**/*.go", "!vendor/**"}}
+	if err := w.AddRecursive(dir, opts); err != nil {
+		t.Fatalf("AddRecursive() failed: %s", err)
+	}
+	if err := w.AddRecursive(dir, opts); err == nil {
+		t.Fatal("expected an error adding a watched tree again")
+	}
+
+	// the files left out by the patterns are not reported
+	for _, name := range []string{"b.txt", "vendor/pkg/v.go", "sub/deep/c.go"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte("xx"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c := filepath.Join(dir, "sub", "deep", "c.go")
+	expectEvent(t, w, Event{Name: c, Op: Write})
+
+	// the new directories are watched, and their files reported
+	inner := filepath.Join(dir, "sub", "new", "inner")
+	if err := os.MkdirAll(inner, 0755); err != nil {
+		t.Fatal(err)
+	}
+	d := filepath.Join(inner, "d.go")
+	if err := ioutil.WriteFile(d, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	expectEvent(t, w, Event{Name: d, Op: Create})
+
+	if err := w.Remove(dir); err != nil {
+		t.Fatalf("Remove() failed: %s", err)
+	}
+	if err := w.Remove(dir); err == nil {
+		t.Fatal("expected an error removing a removed watch")
+	}
+}
@@ -76,21 +76,117 @@ type Marshaler interface {
 // See the documentation of Marshal for the format of tags and a list of
 // supported tag options.
 //
+// If out is a *Node, the document is decoded into it as a node tree
+// instead, preserving the comments, styles and anchors found in the
+// input. See Node for details.
+//
+// Documents exceeding DefaultLimits are rejected with a *LimitError.
+// UnmarshalWithOptions enforces other limits for a single call.
+//
 func Unmarshal(in []byte, out interface{}) (err error) {
+	return unmarshal(in, out, decodeOptions{limits: DefaultLimits})
+}
+
+// UnmarshalStrict is like Unmarshal except that any fields that are found
+// in the data that do not have corresponding struct members, or mapping
+// keys that are duplicates, will result in an error. All such problems
+// are reported in the returned *yaml.TypeError, along with the type
+// mismatches reported by Unmarshal.
+func UnmarshalStrict(in []byte, out interface{}) (err error) {
+	return unmarshal(in, out, decodeOptions{strict: true, limits: DefaultLimits})
+}
+
+// UnmarshalOptions holds the settings of UnmarshalWithOptions.
+type UnmarshalOptions struct {
+	// Strict reports unknown fields and duplicate keys as UnmarshalStrict
+	// does.
+	Strict bool
+
+	// Ordered decodes the mappings found for interface{} values as a
+	// MapSlice, as Decoder.SetOrdered does.
+	Ordered bool
+
+	// Limits are the limits the document must be within, or DefaultLimits
+	// if nil.
+	Limits *Limits
+}
+
+// UnmarshalWithOptions is like Unmarshal, except that the document is
+// decoded with the settings in opts, which apply to this call only.
+func UnmarshalWithOptions(in []byte, out interface{}, opts UnmarshalOptions) (err error) {
+	limits := DefaultLimits
+	if opts.Limits != nil {
+		limits = *opts.Limits
+	}
+	return unmarshal(in, out, decodeOptions{strict: opts.Strict, ordered: opts.Ordered, limits: limits})
+}
+
+// decodeOptions holds the settings that documents are decoded with.
+type decodeOptions struct {
+	strict  bool
+	ordered bool
+	limits  Limits
+	tree    *nodeTree // The Node tree the document was converted from, if any.
+}
+
+func unmarshal(in []byte, out interface{}, opts decodeOptions) (err error) {
 	defer handleErr(&err)
-	d := newDecoder()
 	p := newParser(in)
 	defer p.destroy()
-	node := p.parse()
-	if node != nil {
-		v := reflect.ValueOf(out)
-		if v.Kind() == reflect.Ptr && !v.IsNil() {
-			v = v.Elem()
+	l := &limiter{limits: opts.limits}
+	l.attach(p)
+	if n, ok := out.(*Node); ok {
+		var doc Node
+		c := newComposer(p, &source{buf: in})
+		if c.document(&doc) {
+			l.checkSize(doc.Line-1, p.event.start_mark.index)
+			checkNodeDepth(&doc, opts.limits)
 		}
-		d.unmarshal(node, v)
+		*n = doc
+		return nil
 	}
-	if len(d.terrors) > 0 {
-		return &TypeError{d.terrors}
+	if v := reflect.ValueOf(out); v.IsValid() && typeHooks(v.Type())&hookUnmarshaler != 0 {
+		// NodeUnmarshalers are handed the nodes they are decoded from.
+		var doc Node
+		c := newComposer(p, &source{buf: in})
+		opts.tree = c.track()
+		if !c.document(&doc) {
+			return nil
+		}
+		l.checkSize(doc.Line-1, p.event.start_mark.index)
+		return decode(doc.internal(opts.tree), out, opts)
+	}
+	node := p.parse()
+	if node == nil {
+		return nil
+	}
+	l.checkSize(node.line, p.event.start_mark.index)
+	return decode(node, out, opts)
+}
+
+// decode unmarshals the node tree n into out, reporting the values that
+// could not be decoded, and in strict mode the unknown fields and
+// duplicated keys, as a *TypeError. Nothing is decoded if n exceeds the
+// depth or alias expansion limits.
+func decode(n *node, out interface{}, opts decodeOptions) error {
+	checkLimits(n, opts.limits)
+	d := newDecoder()
+	d.tree = opts.tree
+	if opts.ordered {
+		d.mapType = mapSliceType
+	}
+	v := reflect.ValueOf(out)
+	if v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	var terrors []string
+	if opts.strict && v.IsValid() {
+		terrors = checkStrict(n, v.Type())
+	}
+	d.unmarshal(n, v)
+	terrors = append(terrors, d.terrors...)
+	if len(terrors) > 0 {
+		return &TypeError{terrors}
 	}
 	return nil
 }
@@ -119,10 +215,13 @@ func Unmarshal(in []byte, out interface{}) (err error) {
 //     flow         Marshal using a flow style (useful for structs,
 //                  sequences and maps).
 //
-//     inline       Inline the field, which must be a struct or a map,
-//                  causing all of its fields or keys to be processed as if
-//                  they were part of the outer struct. For maps, keys must
-//                  not conflict with the yaml keys of other struct fields.
+//     inline       Inline the field, which must be a struct, a map or a
+//                  MapSlice, causing all of its fields or keys to be
+//                  processed as if they were part of the outer struct.
+//                  For maps, keys must not conflict with the yaml keys of
+//                  other struct fields. A MapSlice keeps its keys in the
+//                  order they are found in the document, and they are
+//                  written after the other struct fields.
 //
 // In addition, if the key is "-", the field is ignored.
 //
@@ -135,8 +234,28 @@ func Unmarshal(in []byte, out interface{}) (err error) {
 //     yaml.Marshal(&T{B: 2}) // Returns "b: 2\n"
 //     yaml.Marshal(&T{F: 1}} // Returns "a: 1\nb: 0\n"
 //
+// If in is a Node or a *Node, the node tree is written out as is, including
+// its comments, styles and anchors.
+//
 func Marshal(in interface{}) (out []byte, err error) {
 	defer handleErr(&err)
+	switch n := in.(type) {
+	case *Node:
+		p := newNodePrinter()
+		p.document(n, false)
+		return p.out, nil
+	case Node:
+		p := newNodePrinter()
+		p.document(&n, false)
+		return p.out, nil
+	}
+	if v := reflect.ValueOf(in); valueHooks(v)&(hookInline|hookMarshaler) != 0 {
+		e := newEncoder()
+		defer e.destroy()
+		e.emitNode(valueNode(v))
+		e.finish()
+		return e.out, nil
+	}
 	e := newEncoder()
 	defer e.destroy()
 	e.marshal("", reflect.ValueOf(in))
@@ -193,6 +312,10 @@ type structInfo struct {
 	// InlineMap is the number of the field in the struct that
 	// contains an ,inline map, or -1 if there's none.
 	InlineMap int
+
+	// InlineSlice is the number of the field in the struct that
+	// contains an ,inline MapSlice, or -1 if there's none.
+	InlineSlice int
 }
 
 type fieldInfo struct {
@@ -220,6 +343,7 @@ func getStructInfo(st reflect.Type) (*structInfo, error) {
 	fieldsMap := make(map[string]fieldInfo)
 	fieldsList := make([]fieldInfo, 0, n)
 	inlineMap := -1
+	inlineSlice := -1
 	for i := 0; i != n; i++ {
 		field := st.Field(i)
 		if field.PkgPath != "" && !field.Anonymous {
@@ -257,13 +381,21 @@ func getStructInfo(st reflect.Type) (*structInfo, error) {
 		if inline {
 			switch field.Type.Kind() {
 			case reflect.Map:
-				if inlineMap >= 0 {
+				if inlineMap >= 0 || inlineSlice >= 0 {
 					return nil, errors.New("Multiple ,inline maps in struct " + st.String())
 				}
 				if field.Type.Key() != reflect.TypeOf("") {
 					return nil, errors.New("Option ,inline needs a map with string keys in struct " + st.String())
 				}
 				inlineMap = info.Num
+			case reflect.Slice:
+				if field.Type.Elem() != mapItemType {
+					return nil, errors.New("Option ,inline needs a struct value, a map or a MapSlice field in struct " + st.String())
+				}
+				if inlineMap >= 0 || inlineSlice >= 0 {
+					return nil, errors.New("Multiple ,inline maps in struct " + st.String())
+				}
+				inlineSlice = info.Num
 			case reflect.Struct:
 				sinfo, err := getStructInfo(field.Type)
 				if err != nil {
@@ -304,7 +436,7 @@ func getStructInfo(st reflect.Type) (*structInfo, error) {
 		fieldsMap[info.Key] = info
 	}
 
-	sinfo = &structInfo{fieldsMap, fieldsList, inlineMap}
+	sinfo = &structInfo{fieldsMap, fieldsList, inlineMap, inlineSlice}
 
 	fieldMapMutex.Lock()
 	structMap[st] = sinfo
@@ -8,7 +8,6 @@ package altsrc
 import (
 	"fmt"
 	"io/ioutil"
-	"net/http"
 	"net/url"
 	"os"
 	"runtime"
@@ -25,14 +24,18 @@ type yamlSourceContext struct {
 
 // NewYamlSourceFromFile creates a new Yaml InputSourceContext from a filepath.
 func NewYamlSourceFromFile(file string) (InputSourceContext, error) {
+	return newYamlSource(defaultRemoteLoader, file)
+}
+
+func newYamlSource(loader *RemoteLoader, file string) (InputSourceContext, error) {
 	ysc := &yamlSourceContext{FilePath: file}
 	var results map[interface{}]interface{}
-	err := readCommandYaml(ysc.FilePath, &results)
+	err := readCommandYaml(loader, ysc.FilePath, &results)
 	if err != nil {
 		return nil, fmt.Errorf("Unable to load Yaml file '%s': inner error: \n'%v'", ysc.FilePath, err.Error())
 	}
 
-	return &MapInputSource{valueMap: results}, nil
+	return newMapInputSource(ysc.FilePath, results), nil
 }
 
 // NewYamlSourceFromFlagFunc creates a new Yaml InputSourceContext from a provided flag name and source context.
@@ -43,8 +46,8 @@ func NewYamlSourceFromFlagFunc(flagFileName string) func(context *cli.Context) (
 	}
 }
 
-func readCommandYaml(filePath string, container interface{}) (err error) {
-	b, err := loadDataFrom(filePath)
+func readCommandYaml(loader *RemoteLoader, filePath string, container interface{}) (err error) {
+	b, err := loadDataFrom(loader, filePath)
 	if err != nil {
 		return err
 	}
@@ -58,7 +61,7 @@ func readCommandYaml(filePath string, container interface{}) (err error) {
 	return
 }
 
-func loadDataFrom(filePath string) ([]byte, error) {
+func loadDataFrom(loader *RemoteLoader, filePath string) ([]byte, error) {
 	u, err := url.Parse(filePath)
 	if err != nil {
 		return nil, err
@@ -67,11 +70,7 @@ func loadDataFrom(filePath string) ([]byte, error) {
 	if u.Host != "" { // i have a host, now do i support the scheme?
 		switch u.Scheme {
 		case "http", "https":
-			res, err := http.Get(filePath)
-			if err != nil {
-				return nil, err
-			}
-			return ioutil.ReadAll(res.Body)
+			return loader.Load(filePath)
 		default:
 			return nil, fmt.Errorf("scheme of %s is unsupported", filePath)
 		}
@@ -0,0 +1,124 @@
+package yaml
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Mark is a position in the source text of a document.
+type Mark struct {
+	Index  int // The number of characters preceding the position.
+	Line   int // The line number, starting at 1.
+	Column int // The column number, starting at 1.
+}
+
+func newMark(m yaml_mark_t) Mark {
+	return Mark{Index: m.index, Line: m.line + 1, Column: m.column + 1}
+}
+
+// nodeMarks holds where the text of a node starts and ends.
+type nodeMarks struct {
+	start, end yaml_mark_t
+}
+
+// nodeTree relates the nodes of a composed document to the nodes the
+// decoder works on, and holds their positions when they are known.
+type nodeTree struct {
+	nodes map[*node]*Node
+	marks map[*Node]nodeMarks
+}
+
+// NodeContext describes the node being decoded into a NodeUnmarshaler.
+type NodeContext struct {
+	// Node is the node being decoded. It must not be modified.
+	Node *Node
+
+	// Start and End are where the text of the node starts and ends in the
+	// source document. End is the zero Mark when it is not known, as when
+	// decoding with Node.Decode.
+	Start, End Mark
+}
+
+// Errorf returns a *TypeError reporting the given problem with the node
+// at its line and column, so that it is reported among the other errors
+// of the document.
+func (ctx *NodeContext) Errorf(format string, args ...interface{}) error {
+	msg := fmt.Sprintf("line %d, column %d: ", ctx.Start.Line, ctx.Start.Column) + fmt.Sprintf(format, args...)
+	return &TypeError{[]string{msg}}
+}
+
+// The NodeUnmarshaler interface may be implemented by types to customize
+// their behavior when being unmarshaled from a YAML document. Unlike
+// with Unmarshaler, the UnmarshalYAMLNode method is also told about the
+// node being decoded, including its kind, tag, style and position in the
+// document. The unmarshal function decodes the node into the value
+// provided, as it does for Unmarshaler. Errors returned by ctx.Errorf are
+// reported as type errors, allowing decoding to continue.
+type NodeUnmarshaler interface {
+	UnmarshalYAMLNode(ctx *NodeContext, unmarshal func(interface{}) error) error
+}
+
+// The NodeMarshaler interface may be implemented by types to customize
+// their behavior when being marshaled into a YAML document. The node tree
+// returned is written out as is, with its tags and styles. Returning a nil
+// node causes the value to be marshaled as null.
+type NodeMarshaler interface {
+	MarshalYAMLNode() (*Node, error)
+}
+
+// callNodeUnmarshaler hands n to the NodeUnmarshaler u, collecting the
+// type errors it reports as callUnmarshaler does. Unlike with Unmarshalers,
+// the value is kept when u reports type errors, so that decoding may go on
+// past them.
+func (d *decoder) callNodeUnmarshaler(n *node, u NodeUnmarshaler) (good bool) {
+	terrlen := len(d.terrors)
+	err := u.UnmarshalYAMLNode(d.nodeContext(n), func(v interface{}) (err error) {
+		defer handleErr(&err)
+		d.unmarshal(n, reflect.ValueOf(v))
+		if len(d.terrors) > terrlen {
+			issues := d.terrors[terrlen:]
+			d.terrors = d.terrors[:terrlen]
+			return &TypeError{issues}
+		}
+		return nil
+	})
+	if e, ok := err.(*TypeError); ok {
+		d.terrors = append(d.terrors, e.Errors...)
+		return true
+	}
+	if err != nil {
+		fail(err)
+	}
+	return true
+}
+
+// nodeContext describes n to the NodeUnmarshaler it is decoded into, with
+// the Node it was converted from if there is one.
+func (d *decoder) nodeContext(n *node) *NodeContext {
+	if d.tree != nil {
+		if pn := d.tree.nodes[n]; pn != nil {
+			ctx := &NodeContext{Node: pn, Start: Mark{Line: pn.Line, Column: pn.Column}}
+			if m, ok := d.tree.marks[pn]; ok {
+				ctx.Start = newMark(m.start)
+				ctx.End = newMark(m.end)
+			}
+			return ctx
+		}
+	}
+	pn := &Node{Value: n.value, Tag: shortTag(n.tag), Line: n.line + 1, Column: n.column + 1}
+	switch n.kind {
+	case scalarNode:
+		pn.Kind = ScalarNode
+		if n.tag == "" && n.implicit {
+			tag, _ := resolve("", n.value)
+			pn.Tag = shortTag(tag)
+		} else if n.tag == "" {
+			pn.Tag = "!!str"
+		}
+	case mappingNode:
+		pn.Kind = MappingNode
+	case sequenceNode:
+		pn.Kind = SequenceNode
+	}
+	return &NodeContext{Node: pn, Start: Mark{Line: pn.Line, Column: pn.Column}}
+}
@@ -0,0 +1,156 @@
+package yaml_test
+
+import (
+	"strconv"
+	"strings"
+
+	. "gopkg.in/check.v1"
+	"gopkg.in/yaml.v2"
+)
+
+// port is a port number that reports where invalid ports are found.
+type port struct {
+	Number int
+	Tag    string
+	Style  yaml.Style
+	Start  yaml.Mark
+	End    yaml.Mark
+}
+
+func (p *port) UnmarshalYAMLNode(ctx *yaml.NodeContext, unmarshal func(interface{}) error) error {
+	p.Tag, p.Style, p.Start, p.End = ctx.Node.Tag, ctx.Node.Style, ctx.Start, ctx.End
+	if err := unmarshal(&p.Number); err != nil {
+		return err
+	}
+	if p.Number < 1 || p.Number > 65535 {
+		return ctx.Errorf("port %d out of range", p.Number)
+	}
+	return nil
+}
+
+type portConfig struct {
+	Main    port
+	Backup  *port
+	Extra   []port
+	ByName  map[string]*port
+	Comment string
+}
+
+func (s *S) TestNodeUnmarshaler(c *C) {
+	data := "main: 80\nbackup: !!int '8080'\nextra: [1, 2]\nbyname:\n  a: !!int 3\ncomment: x\n"
+	var v portConfig
+	err := yaml.Unmarshal([]byte(data), &v)
+	c.Assert(err, IsNil)
+	c.Assert(v.Main, DeepEquals, port{80, "!!int", 0, yaml.Mark{6, 1, 7}, yaml.Mark{8, 1, 9}})
+	c.Assert(v.Backup.Tag, Equals, "!!int")
+	c.Assert(v.Backup.Style, Equals, yaml.TaggedStyle|yaml.SingleQuotedStyle)
+	c.Assert(v.Backup.Number, Equals, 8080)
+	c.Assert(v.Backup.Start, Equals, yaml.Mark{17, 2, 9})
+	c.Assert(v.Backup.End, Equals, yaml.Mark{29, 2, 21})
+	c.Assert(v.Extra, HasLen, 2)
+	c.Assert(v.Extra[1].Number, Equals, 2)
+	c.Assert(v.Extra[1].Start, Equals, yaml.Mark{41, 3, 12})
+	c.Assert(v.ByName["a"].Number, Equals, 3)
+	c.Assert(v.ByName["a"].Style, Equals, yaml.TaggedStyle)
+	c.Assert(v.Comment, Equals, "x")
+
+	// Decoding a node tree reports the start of the nodes only.
+	var n yaml.Node
+	c.Assert(yaml.Unmarshal([]byte(data), &n), IsNil)
+	v = portConfig{}
+	c.Assert(n.Decode(&v), IsNil)
+	c.Assert(v.Main.Start, Equals, yaml.Mark{0, 1, 7})
+	c.Assert(v.Main.End, Equals, yaml.Mark{})
+	c.Assert(v.Backup.Number, Equals, 8080)
+
+	// As does the stream decoder.
+	dec := yaml.NewDecoder(strings.NewReader("main: 1\n---\nmain: 2\nextra: [3]\n"))
+	for i := 1; i <= 2; i++ {
+		v = portConfig{}
+		c.Assert(dec.Decode(&v), IsNil)
+		c.Assert(v.Main.Number, Equals, i)
+		c.Assert(v.Main.Start.Line, Equals, 2*i-1)
+		c.Assert(v.Main.End.Column, Equals, 8)
+	}
+	c.Assert(v.Extra[0].Start, Equals, yaml.Mark{28, 4, 9})
+}
+
+func (s *S) TestNodeUnmarshalerErrors(c *C) {
+	data := "main: 0\nextra:\n- 1\n- x\n- 70000\n"
+	var v portConfig
+	err := yaml.Unmarshal([]byte(data), &v)
+	c.Assert(err, ErrorMatches, "yaml: unmarshal errors:\n"+
+		"  line 1, column 7: port 0 out of range\n"+
+		"  line 4, column 3: cannot unmarshal !!str `x` into int\n"+
+		"  line 5, column 3: port 70000 out of range")
+	c.Assert(v.Extra, HasLen, 3)
+	c.Assert(v.Extra[0].Number, Equals, 1)
+}
+
+func (s *S) TestTypeErrorColumns(c *C) {
+	var v struct {
+		A, B int
+		C    []int
+	}
+	err := yaml.Unmarshal([]byte("a: x\nc: [1, z, 3, zz]\nb: x\n"), &v)
+	c.Assert(err, ErrorMatches, "yaml: unmarshal errors:\n"+
+		"  line 1, column 4: cannot unmarshal !!str `x` into int\n"+
+		"  line 2, column 8: cannot unmarshal !!str `z` into int\n"+
+		"  line 2, column 14: cannot unmarshal !!str `zz` into int\n"+
+		"  line 3, column 4: cannot unmarshal !!str `x` into int")
+
+	// Equal values on the same line are told apart.
+	err = yaml.Unmarshal([]byte("{a: x, b: x}"), &v)
+	c.Assert(err, ErrorMatches, "yaml: unmarshal errors:\n"+
+		"  line 1, column 5: cannot unmarshal !!str `x` into int\n"+
+		"  line 1, column 11: cannot unmarshal !!str `x` into int")
+
+	var m map[string]string
+	err = yaml.Unmarshal([]byte("a: b\nc: [d]\n"), &m)
+	c.Assert(err, ErrorMatches, "yaml: unmarshal errors:\n"+
+		"  line 2, column 4: cannot unmarshal !!seq into string")
+}
+
+// hexNumber marshals into a hexadecimal integer.
+type hexNumber int
+
+func (h hexNumber) MarshalYAMLNode() (*yaml.Node, error) {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: "0x" + strings.ToUpper(strconv.FormatInt(int64(h), 16))}, nil
+}
+
+// pair marshals into a flow sequence.
+type pair [2]string
+
+func (p pair) MarshalYAMLNode() (*yaml.Node, error) {
+	return &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle, Content: []*yaml.Node{
+		{Kind: yaml.ScalarNode, Value: p[0], Style: yaml.DoubleQuotedStyle},
+		{Kind: yaml.ScalarNode, Value: p[1]},
+	}}, nil
+}
+
+func (s *S) TestNodeMarshaler(c *C) {
+	v := struct {
+		Mask  hexNumber
+		Pairs []pair
+		None  *pair
+	}{255, []pair{{"a", "b"}}, nil}
+	data, err := yaml.Marshal(v)
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "mask: 0xFF\npairs:\n- [\"a\", b]\nnone: null\n")
+
+	var out struct {
+		Mask  int
+		Pairs [][]string
+	}
+	c.Assert(yaml.Unmarshal(data, &out), IsNil)
+	c.Assert(out.Mask, Equals, 255)
+	c.Assert(out.Pairs, DeepEquals, [][]string{{"a", "b"}})
+
+	// As are those held by interface values.
+	data, err = yaml.Marshal(map[string]interface{}{"mask": hexNumber(255), "list": []interface{}{pair{"a", "b"}}})
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "list:\n- [\"a\", b]\nmask: 0xFF\n")
+	data, err = yaml.Marshal(map[hexNumber]int{16: 1})
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "0x10: 1\n")
+}
@@ -0,0 +1,122 @@
+package yaml
+
+import (
+	"reflect"
+	"sync"
+)
+
+// The kinds of values that are decoded from the Node tree of a document,
+// for hookUnmarshaler, or encoded through valueNode, for the others.
+const (
+	hookInline      = 1 << iota // Structs with an ,inline MapSlice field.
+	hookUnmarshaler             // NodeUnmarshalers.
+	hookMarshaler               // NodeMarshalers.
+	hookInterface               // Interface values, which may hold any of them.
+)
+
+var (
+	nodeUnmarshalerType = reflect.TypeOf((*NodeUnmarshaler)(nil)).Elem()
+	nodeMarshalerType   = reflect.TypeOf((*NodeMarshaler)(nil)).Elem()
+)
+
+var typeHooksMap = make(map[reflect.Type]int)
+var typeHooksMutex sync.Mutex
+
+// typeHooks returns the kinds of values calling for the Node tree of a
+// document or for valueNode that values of type t may hold, along with
+// hookInterface if they may hold interface values, whose hooks depend on
+// the values they hold.
+func typeHooks(t reflect.Type) int {
+	typeHooksMutex.Lock()
+	defer typeHooksMutex.Unlock()
+	if hooks, found := typeHooksMap[t]; found {
+		return hooks
+	}
+	// The hooks of a type are those of every type it is made of, which
+	// are walked once each, as types may be recursive.
+	hooks := 0
+	seen := map[reflect.Type]bool{t: true}
+	for queue := []reflect.Type{t}; len(queue) > 0; queue = queue[1:] {
+		for _, et := range ownHooks(queue[0], &hooks) {
+			if !seen[et] {
+				seen[et] = true
+				queue = append(queue, et)
+			}
+		}
+	}
+	typeHooksMap[t] = hooks
+	return hooks
+}
+
+// ownHooks adds the hooks of type t itself to hooks, and returns the types
+// that values of type t are made of.
+func ownHooks(t reflect.Type, hooks *int) []reflect.Type {
+	if reflect.PtrTo(t).Implements(nodeUnmarshalerType) {
+		*hooks |= hookUnmarshaler
+	}
+	if t.Implements(nodeMarshalerType) {
+		*hooks |= hookMarshaler
+	}
+	switch t.Kind() {
+	case reflect.Interface:
+		*hooks |= hookInterface
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		return []reflect.Type{t.Elem()}
+	case reflect.Map:
+		return []reflect.Type{t.Key(), t.Elem()}
+	case reflect.Struct:
+		if sinfo, err := getStructInfo(t); err == nil && sinfo.InlineSlice != -1 {
+			*hooks |= hookInline
+		}
+		var types []reflect.Type
+		for i := 0; i < t.NumField(); i++ {
+			if field := t.Field(i); field.PkgPath == "" || field.Anonymous {
+				types = append(types, field.Type)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+// valueHooks returns the kinds of values calling for valueNode that in
+// holds, looking into its interface values as the encoder does.
+func valueHooks(in reflect.Value) int {
+	if !in.IsValid() {
+		return 0
+	}
+	hooks := typeHooks(in.Type())
+	if hooks&(hookInline|hookMarshaler) != 0 || hooks&hookInterface == 0 {
+		return hooks
+	}
+	switch in.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		if !in.IsNil() {
+			hooks |= valueHooks(in.Elem())
+		}
+	case reflect.Map:
+		for _, k := range in.MapKeys() {
+			hooks |= valueHooks(k) | valueHooks(in.MapIndex(k))
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < in.Len(); i++ {
+			hooks |= valueHooks(in.Index(i))
+		}
+	case reflect.Struct:
+		sinfo, err := getStructInfo(in.Type())
+		if err != nil {
+			return hooks
+		}
+		for _, info := range sinfo.FieldsList {
+			if info.Inline == nil {
+				hooks |= valueHooks(in.Field(info.Num))
+			} else {
+				hooks |= valueHooks(in.FieldByIndex(info.Inline))
+			}
+		}
+		if sinfo.InlineMap != -1 {
+			hooks |= valueHooks(in.Field(sinfo.InlineMap))
+		}
+	}
+	return hooks
+}
@@ -0,0 +1,174 @@
+package yaml
+
+import (
+	"fmt"
+)
+
+// Limits bounds the resources that decoding a single document may take,
+// protecting services that decode untrusted input from documents crafted
+// to exhaust them, such as the "billion laughs" ones where a few nested
+// aliases expand into a huge number of values.
+//
+// A zero value in any of the fields disables the respective limit.
+type Limits struct {
+	// MaxDocumentSize is the maximum length of a document, in characters.
+	MaxDocumentSize int
+
+	// MaxDepth is the maximum nesting depth of the collections in a
+	// document.
+	MaxDepth int
+
+	// MaxAliasRatio is the maximum ratio between the number of values
+	// decoded once all aliases are expanded and the number of values
+	// actually present in the document. Documents expanding into fewer
+	// than 400000 values are never rejected by this limit.
+	MaxAliasRatio float64
+}
+
+// DefaultLimits holds the limits enforced by Unmarshal, UnmarshalStrict
+// and by a Decoder whose limits were not changed with SetLimits. Changing
+// it affects every later call in the program: UnmarshalWithOptions and
+// Decoder.SetLimits set the limits of a single call or Decoder instead.
+var DefaultLimits = Limits{
+	MaxDepth:      10000,
+	MaxAliasRatio: 100,
+}
+
+// The number of expanded values below which MaxAliasRatio is not enforced,
+// as small documents legitimately make heavy use of aliases.
+const aliasExpansionGrace = 400000
+
+// A LimitError is returned when decoding a document exceeds one of the
+// limits set for it. No value is decoded from a document that exceeds
+// its limits.
+type LimitError struct {
+	Limit string // The name of the Limits field that was exceeded.
+	Line  int    // The line where the limit was exceeded.
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("yaml: line %d: document exceeds the %s limit", e.Line, e.Limit)
+}
+
+// limiter enforces a set of Limits while a document is parsed and before
+// it is decoded.
+type limiter struct {
+	limits Limits
+	start  int // The index of the first character of the current document.
+}
+
+// attach makes the parser check the limits every time it reads more input,
+// which bounds the work done on a document exceeding them to the size of
+// the parser buffers.
+func (l *limiter) attach(p *parser) {
+	read := p.parser.read_handler
+	p.parser.read_handler = func(parser *yaml_parser_t, buffer []byte) (n int, err error) {
+		line := parser.mark.line + 1
+		if l.limits.MaxDepth > 0 && parser.flow_level+len(parser.indents) > l.limits.MaxDepth {
+			fail(&LimitError{"MaxDepth", line})
+		}
+		if l.limits.MaxDocumentSize > 0 && parser.mark.index-l.start > l.limits.MaxDocumentSize {
+			fail(&LimitError{"MaxDocumentSize", line})
+		}
+		return read(parser, buffer)
+	}
+}
+
+// checkSize verifies that the document starting at the given line and
+// ending at the given index is within the MaxDocumentSize limit.
+func (l *limiter) checkSize(line, end int) {
+	if l.limits.MaxDocumentSize > 0 && end-l.start > l.limits.MaxDocumentSize {
+		fail(&LimitError{"MaxDocumentSize", line + 1})
+	}
+}
+
+// checkLimits verifies that the node tree n is within the depth and alias
+// expansion limits, before any value is decoded out of it.
+func checkLimits(n *node, limits Limits) {
+	if limits.MaxDepth > 0 {
+		if deep := deepest(n, 0, limits.MaxDepth); deep != nil {
+			fail(&LimitError{"MaxDepth", deep.line + 1})
+		}
+	}
+	if limits.MaxAliasRatio > 0 {
+		e := &expansion{doc: n, sizes: make(map[*node]int)}
+		expanded := e.size(n)
+		if expanded > aliasExpansionGrace && float64(expanded) > limits.MaxAliasRatio*float64(len(e.sizes)) {
+			fail(&LimitError{"MaxAliasRatio", n.line + 1})
+		}
+	}
+}
+
+// checkNodeDepth verifies that the node tree n is within the depth limit.
+// Aliases are not expanded in node trees, so the alias expansion limit
+// does not apply.
+func checkNodeDepth(n *Node, limits Limits) {
+	if limits.MaxDepth > 0 {
+		if deep := deepestNode(n, 0, limits.MaxDepth); deep != nil {
+			fail(&LimitError{"MaxDepth", deep.Line})
+		}
+	}
+}
+
+func deepestNode(n *Node, depth, max int) *Node {
+	if n.Kind == MappingNode || n.Kind == SequenceNode {
+		if depth++; depth > max {
+			return n
+		}
+	}
+	for _, c := range n.Content {
+		if deep := deepestNode(c, depth, max); deep != nil {
+			return deep
+		}
+	}
+	return nil
+}
+
+// deepest returns the first node of n nested deeper than max, if any.
+func deepest(n *node, depth, max int) *node {
+	if n.kind == mappingNode || n.kind == sequenceNode {
+		if depth++; depth > max {
+			return n
+		}
+	}
+	for _, c := range n.children {
+		if deep := deepest(c, depth, max); deep != nil {
+			return deep
+		}
+	}
+	return nil
+}
+
+// expansion computes how many values a node tree decodes into once its
+// aliases are expanded, without actually expanding them.
+type expansion struct {
+	doc   *node
+	sizes map[*node]int
+}
+
+func (e *expansion) size(n *node) int {
+	if n.kind == documentNode {
+		e.doc = n
+	}
+	if size, ok := e.sizes[n]; ok {
+		return size
+	}
+	// Aliases referencing a node that contains them are rejected by the
+	// decoder, so they count as a single value here.
+	e.sizes[n] = 1
+	size := 1
+	if n.kind == aliasNode {
+		if an := e.doc.anchors[n.value]; an != nil {
+			size = e.size(an)
+		}
+	}
+	for _, c := range n.children {
+		size += e.size(c)
+		if size > aliasExpansionGrace*1000 {
+			// Large enough to be rejected, and far from overflowing.
+			break
+		}
+	}
+	e.sizes[n] = size
+	return size
+}
@@ -0,0 +1,136 @@
+package yaml_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	. "gopkg.in/check.v1"
+	"gopkg.in/yaml.v2"
+)
+
+// billionLaughs expands into more than a billion values.
+const billionLaughs = `
+a: &a [lol, lol, lol, lol, lol, lol, lol, lol, lol]
+b: &b [*a, *a, *a, *a, *a, *a, *a, *a, *a]
+c: &c [*b, *b, *b, *b, *b, *b, *b, *b, *b]
+d: &d [*c, *c, *c, *c, *c, *c, *c, *c, *c]
+e: &e [*d, *d, *d, *d, *d, *d, *d, *d, *d]
+f: &f [*e, *e, *e, *e, *e, *e, *e, *e, *e]
+g: &g [*f, *f, *f, *f, *f, *f, *f, *f, *f]
+h: &h [*g, *g, *g, *g, *g, *g, *g, *g, *g]
+i: &i [*h, *h, *h, *h, *h, *h, *h, *h, *h]
+`
+
+func (s *S) TestLimitsAliasRatio(c *C) {
+	var v map[string]interface{}
+	err := yaml.Unmarshal([]byte(billionLaughs), &v)
+	c.Assert(err, ErrorMatches, "yaml: line 2: document exceeds the MaxAliasRatio limit")
+	c.Assert(err, FitsTypeOf, &yaml.LimitError{})
+	c.Assert(v, IsNil)
+
+	// Documents making moderate use of aliases are accepted.
+	data := billionLaughs[:strings.Index(billionLaughs, "f:")]
+	err = yaml.Unmarshal([]byte(data), &v)
+	c.Assert(err, IsNil)
+	c.Assert(v["e"], HasLen, 9)
+
+	// The node tree holds the aliases unexpanded, but decoding it is
+	// limited alike.
+	var n yaml.Node
+	err = yaml.Unmarshal([]byte(billionLaughs), &n)
+	c.Assert(err, IsNil)
+	err = n.Decode(&v)
+	c.Assert(err, ErrorMatches, ".*MaxAliasRatio limit")
+}
+
+func (s *S) TestLimitsDepth(c *C) {
+	for _, data := range []string{
+		strings.Repeat("[", 20000) + strings.Repeat("]", 20000),
+		strings.Repeat("- ", 20000) + "x\n",
+	} {
+		var v interface{}
+		err := yaml.Unmarshal([]byte(data), &v)
+		c.Assert(err, ErrorMatches, "yaml: line 1: document exceeds the MaxDepth limit")
+		c.Assert(v, IsNil)
+	}
+
+	dec := yaml.NewDecoder(strings.NewReader("a: {b: [c]}\n---\na: [b]\n"))
+	dec.SetLimits(yaml.Limits{MaxDepth: 2})
+	var v interface{}
+	err := dec.Decode(&v)
+	c.Assert(err, ErrorMatches, "yaml: line 1: document exceeds the MaxDepth limit")
+
+	// Node trees are limited alike.
+	var n yaml.Node
+	err = dec.Decode(&n)
+	c.Assert(err, IsNil)
+	dec = yaml.NewDecoder(strings.NewReader("a:\n  b: [c]\n"))
+	dec.SetLimits(yaml.Limits{MaxDepth: 2})
+	err = dec.Decode(&n)
+	c.Assert(err, ErrorMatches, "yaml: line 2: document exceeds the MaxDepth limit")
+	opts := yaml.UnmarshalOptions{Limits: &yaml.Limits{MaxDepth: 2}}
+	err = yaml.UnmarshalWithOptions([]byte("a:\n  b: [c]\n"), &n, opts)
+	c.Assert(err, ErrorMatches, "yaml: line 2: document exceeds the MaxDepth limit")
+}
+
+func (s *S) TestLimitsPerCall(c *C) {
+	data := []byte("a: {b: [c]}\n")
+	var v interface{}
+	opts := yaml.UnmarshalOptions{Limits: &yaml.Limits{MaxDepth: 2}}
+	err := yaml.UnmarshalWithOptions(data, &v, opts)
+	c.Assert(err, ErrorMatches, "yaml: line 1: document exceeds the MaxDepth limit")
+	c.Assert(v, IsNil)
+
+	// The limits of one call leave the others alone.
+	c.Assert(yaml.Unmarshal(data, &v), IsNil)
+	deep := []byte(strings.Repeat("[", 20000) + strings.Repeat("]", 20000))
+	c.Assert(yaml.UnmarshalWithOptions(deep, &v, yaml.UnmarshalOptions{Limits: &yaml.Limits{}}), IsNil)
+	c.Assert(yaml.Unmarshal(deep, &v), ErrorMatches, ".*MaxDepth limit")
+
+	err = yaml.UnmarshalWithOptions([]byte("a: 1\nb: 2\n"), &struct{ A int }{}, yaml.UnmarshalOptions{Strict: true})
+	c.Assert(err, ErrorMatches, "(?s).*field b not found.*")
+}
+
+func (s *S) TestLimitsDocumentSize(c *C) {
+	data := "a: " + strings.Repeat("x", 100) + "\n---\nb: 1\n---\nc: " + strings.Repeat("y", 10000) + "\n"
+	dec := yaml.NewDecoder(strings.NewReader(data))
+	dec.SetLimits(yaml.Limits{MaxDocumentSize: 200})
+	var v map[string]string
+	c.Assert(dec.Decode(&v), IsNil)
+	c.Assert(v["a"], HasLen, 100)
+	v = nil
+	c.Assert(dec.Decode(&v), IsNil)
+	c.Assert(v, DeepEquals, map[string]string{"b": "1"})
+	v = nil
+	err := dec.Decode(&v)
+	c.Assert(err, ErrorMatches, "yaml: line 5: document exceeds the MaxDocumentSize limit")
+	c.Assert(v, IsNil)
+
+	// The limit is enforced as the input is read, long before the whole
+	// document is.
+	r := &countingReader{r: io.MultiReader(strings.NewReader("a: "), bytes.NewReader(bytes.Repeat([]byte("x"), 1<<24)))}
+	dec = yaml.NewDecoder(r)
+	dec.SetLimits(yaml.Limits{MaxDocumentSize: 1000})
+	err = dec.Decode(&v)
+	c.Assert(err, ErrorMatches, ".*MaxDocumentSize limit")
+	c.Assert(r.n < 1<<16, Equals, true)
+}
+
+func (s *S) TestLimitsDisabled(c *C) {
+	dec := yaml.NewDecoder(strings.NewReader(strings.Repeat("[", 200) + strings.Repeat("]", 200)))
+	dec.SetLimits(yaml.Limits{})
+	var v interface{}
+	c.Assert(dec.Decode(&v), IsNil)
+}
+
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += n
+	return n, err
+}
@@ -0,0 +1,669 @@
+package yaml
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+)
+
+// Kind identifies the type of a Node.
+type Kind uint32
+
+const (
+	DocumentNode Kind = 1 << iota
+	SequenceNode
+	MappingNode
+	ScalarNode
+	AliasNode
+)
+
+// Style holds the presentation details of a Node.
+type Style uint32
+
+const (
+	TaggedStyle Style = 1 << iota
+	DoubleQuotedStyle
+	SingleQuotedStyle
+	LiteralStyle
+	FoldedStyle
+	FlowStyle
+)
+
+// Node represents an element in the YAML document hierarchy. While documents
+// are typically encoded and decoded into higher level types, such as structs
+// and maps, Node is an intermediate representation that allows detailed
+// control over the content being decoded or encoded, including the comments,
+// styles and anchors found in the original text.
+//
+// Values that make use of the Node type interact with the yaml package in the
+// same way any other type would do, by encoding and decoding yaml data
+// directly or indirectly into them. A Node held by a struct field, a map or
+// a slice is decoded from the node found in its place, and encoded as is,
+// though only the comments of a Node given to Marshal itself are written.
+//
+// For example:
+//
+//     var n yaml.Node
+//     err := yaml.Unmarshal(data, &n)
+//     ...
+//     n.Content[0].Content[1].Value = "1.2.4"
+//     data, err = yaml.Marshal(&n)
+//
+type Node struct {
+	// Kind defines whether the node is a document, a mapping, a sequence,
+	// a scalar value, or an alias to another node.
+	Kind Kind
+
+	// Style allows customizing the apperance of the node in the tree.
+	Style Style
+
+	// Tag holds the YAML tag defining the data type for the value.
+	// When decoding, this field will always be set to the resolved tag,
+	// even when it wasn't explicitly provided in the YAML content.
+	// When encoding, if this field is unset the value type will be
+	// implied from the node properties, and if it is set, it will only
+	// be serialized into the representation if TaggedStyle is used or
+	// the implicit tag diverges from the provided one.
+	Tag string
+
+	// Value holds the unescaped and unquoted represenation of the value.
+	Value string
+
+	// Anchor holds the anchor name for this node, which allows aliases
+	// to point to it.
+	Anchor string
+
+	// Alias holds the node that this alias points to. Only valid when
+	// Kind is AliasNode.
+	Alias *Node
+
+	// Content holds contained nodes for documents, mappings, and sequences.
+	// Mapping keys and values are stored as consecutive pairs.
+	Content []*Node
+
+	// HeadComment holds any comments in the lines preceding the node and
+	// not separated by an empty line.
+	HeadComment string
+
+	// LineComment holds any comments at the end of the line where the
+	// node is in.
+	LineComment string
+
+	// FootComment holds any comments following the node and before
+	// the next node at the same or a lower indentation.
+	FootComment string
+
+	// Line and Column hold the node position in the decoded YAML text.
+	// These fields are not respected when encoding the node.
+	Line   int
+	Column int
+}
+
+// ShortTag returns the tag of the node in its short form, resolving
+// the implicit tag of plain scalars when none is set.
+func (n *Node) ShortTag() string {
+	if n.Tag != "" {
+		return shortTag(n.Tag)
+	}
+	switch n.Kind {
+	case MappingNode:
+		return "!!map"
+	case SequenceNode:
+		return "!!seq"
+	case AliasNode:
+		if n.Alias != nil {
+			return n.Alias.ShortTag()
+		}
+	case ScalarNode:
+		if n.Style&(DoubleQuotedStyle|SingleQuotedStyle|LiteralStyle|FoldedStyle) != 0 {
+			return "!!str"
+		}
+		tag, _ := resolve("", n.Value)
+		return shortTag(tag)
+	}
+	return ""
+}
+
+// Decode decodes the node and stores its data into the value pointed to by v.
+//
+// See the documentation for Unmarshal for details about the
+// conversion of YAML into a Go value.
+func (n *Node) Decode(v interface{}) (err error) {
+	defer handleErr(&err)
+	if n.Kind == 0 {
+		return nil
+	}
+	tree := &nodeTree{nodes: make(map[*node]*Node)}
+	return decode(n.internal(tree), v, decodeOptions{limits: DefaultLimits, tree: tree})
+}
+
+// Encode encodes value v and stores its representation in n.
+//
+// See the documentation for Marshal for details about the
+// conversion of Go values into YAML.
+func (n *Node) Encode(v interface{}) (err error) {
+	defer handleErr(&err)
+	*n = *valueNode(reflect.ValueOf(v))
+	return nil
+}
+
+// UnmarshalYAMLNode implements NodeUnmarshaler, so that a Node within the
+// value being decoded holds the node found in its place. A null value
+// leaves the Node unset, as it does other values.
+func (n *Node) UnmarshalYAMLNode(ctx *NodeContext, unmarshal func(interface{}) error) error {
+	*n = *ctx.Node
+	return nil
+}
+
+// MarshalYAMLNode implements NodeMarshaler, so that a Node within the value
+// being encoded is written out as is. The zero Node is written as null.
+func (n Node) MarshalYAMLNode() (*Node, error) {
+	if n.Kind == 0 {
+		return nil, nil
+	}
+	return &n, nil
+}
+
+// internal converts n into the tree understood by the decoder, wrapping it
+// into a document so that aliases within it may be resolved. The nodes
+// converted are recorded in tree, if set.
+func (n *Node) internal(tree *nodeTree) *node {
+	doc := &node{kind: documentNode, anchors: make(map[string]*node)}
+	if n.Kind == DocumentNode {
+		doc.line, doc.column = n.Line-1, n.Column-1
+		for _, c := range n.Content {
+			doc.children = append(doc.children, c.toInternal(doc, tree))
+		}
+	} else {
+		doc.children = []*node{n.toInternal(doc, tree)}
+	}
+	return doc
+}
+
+func (n *Node) toInternal(doc *node, tree *nodeTree) *node {
+	in := &node{line: n.Line - 1, column: n.Column - 1, value: n.Value}
+	if tree != nil {
+		tree.nodes[in] = n
+	}
+	switch n.Kind {
+	case ScalarNode:
+		in.kind = scalarNode
+		if n.Tag != "" {
+			in.tag = longTag(n.Tag)
+		} else {
+			in.implicit = n.Style&(DoubleQuotedStyle|SingleQuotedStyle|LiteralStyle|FoldedStyle) == 0
+		}
+	case MappingNode:
+		in.kind = mappingNode
+	case SequenceNode:
+		in.kind = sequenceNode
+	case AliasNode:
+		in.kind = aliasNode
+		in.value = n.Value
+		if n.Alias != nil && in.value == "" {
+			in.value = n.Alias.Anchor
+		}
+	default:
+		failf("cannot decode node of kind %d", n.Kind)
+	}
+	if n.Kind == MappingNode || n.Kind == SequenceNode {
+		if n.Tag != "" && n.Tag != "!!map" && n.Tag != "!!seq" {
+			in.tag = longTag(n.Tag)
+		}
+		for _, c := range n.Content {
+			in.children = append(in.children, c.toInternal(doc, tree))
+		}
+	}
+	if n.Anchor != "" {
+		doc.anchors[n.Anchor] = in
+	}
+	return in
+}
+
+// ----------------------------------------------------------------------------
+// Composer, produces a Node tree out of a libyaml event stream.
+
+// composer builds Node trees out of the events delivered by a parser,
+// attaching the comments found in the source text to the nodes.
+type composer struct {
+	p        *parser
+	src      *source
+	anchors  map[string]*Node
+	aliases  []*Node
+	comments *commentScanner
+	marks    map[*Node]nodeMarks // Where each node starts and ends, if tracked.
+	end      yaml_mark_t         // Where the last event consumed ends.
+}
+
+func newComposer(p *parser, src *source) *composer {
+	return &composer{p: p, src: src}
+}
+
+// document composes the next document in the stream into n, returning
+// false if the end of the stream was reached.
+func (c *composer) document(n *Node) bool {
+	p := c.p
+	if p.event.typ == yaml_STREAM_END_EVENT {
+		return false
+	}
+	if p.event.typ != yaml_DOCUMENT_START_EVENT {
+		panic("expected document start event but got " + fmt.Sprint(p.event.typ))
+	}
+	c.anchors = make(map[string]*Node)
+	c.aliases = nil
+	c.comments = newCommentScanner(c.src)
+	*n = Node{Kind: DocumentNode}
+	c.position(n)
+	p.skip()
+	content := c.node()
+	n.Content = []*Node{content}
+	for _, alias := range c.aliases {
+		if alias.Alias = c.anchors[alias.Value]; alias.Alias == nil {
+			failf("unknown anchor '%s' referenced", alias.Value)
+		}
+	}
+	if p.event.typ != yaml_DOCUMENT_END_EVENT {
+		panic("expected end of document event but got " + fmt.Sprint(p.event.typ))
+	}
+	p.skip()
+	n.HeadComment = c.comments.remaining(content.Line - 1)
+	end := c.comments.end()
+	if p.event.typ != yaml_STREAM_END_EVENT {
+		end = p.event.start_mark.line
+	}
+	c.comments.footers(content, end)
+	n.FootComment = c.comments.remaining(end)
+	return true
+}
+
+func (c *composer) position(n *Node) {
+	n.Line = c.p.event.start_mark.line + 1
+	n.Column = c.p.event.start_mark.column + 1
+}
+
+func (c *composer) anchor(n *Node, anchor []byte) {
+	if anchor != nil {
+		n.Anchor = string(anchor)
+		c.anchors[n.Anchor] = n
+	}
+}
+
+func (c *composer) node() *Node {
+	start := c.p.event.start_mark
+	var n *Node
+	switch c.p.event.typ {
+	case yaml_SCALAR_EVENT:
+		n = c.scalar()
+	case yaml_ALIAS_EVENT:
+		n = c.alias()
+	case yaml_MAPPING_START_EVENT:
+		n = c.mapping()
+	case yaml_SEQUENCE_START_EVENT:
+		n = c.sequence()
+	default:
+		panic("attempted to parse unknown event: " + fmt.Sprint(c.p.event.typ))
+	}
+	if c.marks != nil {
+		c.marks[n] = nodeMarks{start, c.end}
+	}
+	return n
+}
+
+// skip moves on to the next event, recording where the current one ends.
+func (c *composer) skip() {
+	c.end = c.p.event.end_mark
+	c.p.skip()
+}
+
+// track makes the composer record where each node starts and ends, for
+// the documents composed next to be decoded into NodeUnmarshalers.
+func (c *composer) track() *nodeTree {
+	c.marks = make(map[*Node]nodeMarks)
+	return &nodeTree{nodes: make(map[*node]*Node), marks: c.marks}
+}
+
+func (c *composer) tag(n *Node) {
+	if len(c.p.event.tag) > 0 {
+		n.Tag = shortTag(string(c.p.event.tag))
+		n.Style |= TaggedStyle
+	}
+}
+
+func (c *composer) scalar() *Node {
+	e := &c.p.event
+	n := &Node{Kind: ScalarNode, Value: string(e.value)}
+	c.position(n)
+	switch e.scalar_style() {
+	case yaml_DOUBLE_QUOTED_SCALAR_STYLE:
+		n.Style = DoubleQuotedStyle
+	case yaml_SINGLE_QUOTED_SCALAR_STYLE:
+		n.Style = SingleQuotedStyle
+	case yaml_LITERAL_SCALAR_STYLE:
+		n.Style = LiteralStyle
+	case yaml_FOLDED_SCALAR_STYLE:
+		n.Style = FoldedStyle
+	}
+	c.tag(n)
+	if n.Tag == "" {
+		if n.Style == 0 {
+			tag, _ := resolve("", n.Value)
+			n.Tag = shortTag(tag)
+		} else {
+			n.Tag = "!!str"
+		}
+	}
+	c.anchor(n, e.anchor)
+	if n.Style&(LiteralStyle|FoldedStyle) == 0 {
+		n.LineComment = c.comments.after(e.end_mark.line, e.end_mark.column)
+	} else {
+		// The lines following the header hold the text of the scalar.
+		c.comments.block(e.start_mark.line+1, e.end_mark.line)
+	}
+	c.skip()
+	return n
+}
+
+func (c *composer) alias() *Node {
+	e := &c.p.event
+	n := &Node{Kind: AliasNode, Value: string(e.anchor)}
+	c.position(n)
+	n.Alias = c.anchors[n.Value]
+	if n.Alias == nil {
+		// The anchor may still be defined further down the document.
+		c.aliases = append(c.aliases, n)
+	}
+	n.LineComment = c.comments.after(e.end_mark.line, e.end_mark.column)
+	c.skip()
+	return n
+}
+
+func (c *composer) sequence() *Node {
+	e := &c.p.event
+	n := &Node{Kind: SequenceNode, Tag: "!!seq"}
+	c.position(n)
+	if e.sequence_style() == yaml_FLOW_SEQUENCE_STYLE {
+		n.Style = FlowStyle
+	}
+	c.tag(n)
+	c.anchor(n, e.anchor)
+	c.skip()
+	for c.p.event.typ != yaml_SEQUENCE_END_EVENT {
+		item := c.node()
+		if n.Style&FlowStyle == 0 {
+			item.HeadComment = c.comments.above(item.Line-1, n.Column-1)
+		}
+		n.Content = append(n.Content, item)
+	}
+	if n.Style&FlowStyle != 0 {
+		n.LineComment = c.comments.after(c.p.event.end_mark.line, c.p.event.end_mark.column)
+	}
+	c.skip()
+	return n
+}
+
+func (c *composer) mapping() *Node {
+	e := &c.p.event
+	n := &Node{Kind: MappingNode, Tag: "!!map"}
+	c.position(n)
+	if e.mapping_style() == yaml_FLOW_MAPPING_STYLE {
+		n.Style = FlowStyle
+	}
+	c.tag(n)
+	c.anchor(n, e.anchor)
+	c.skip()
+	for c.p.event.typ != yaml_MAPPING_END_EVENT {
+		key := c.node()
+		if n.Style&FlowStyle == 0 {
+			key.HeadComment = c.comments.above(key.Line-1, key.Column-1)
+		}
+		n.Content = append(n.Content, key, c.node())
+	}
+	if n.Style&FlowStyle != 0 {
+		n.LineComment = c.comments.after(c.p.event.end_mark.line, c.p.event.end_mark.column)
+	}
+	c.skip()
+	return n
+}
+
+// ----------------------------------------------------------------------------
+// Comment scanner, recovers comments from the source text.
+
+// source holds the text being parsed, so that comments can be recovered
+// from it. When parsing from a reader, the text is recorded as the parser
+// consumes it and discarded once the documents holding it are decoded.
+type source struct {
+	r     io.Reader
+	buf   []byte
+	first int // The number of the first line held in buf.
+}
+
+func (s *source) Read(p []byte) (n int, err error) {
+	n, err = s.r.Read(p)
+	s.buf = append(s.buf, p[:n]...)
+	return n, err
+}
+
+// discard drops the recorded text preceding the given line.
+func (s *source) discard(line int) {
+	for s.first < line {
+		i := bytes.IndexByte(s.buf, '\n')
+		if i < 0 {
+			break
+		}
+		s.buf = s.buf[i+1:]
+		s.first++
+	}
+}
+
+// commentScanner locates the comments in the source text of a document.
+// The libyaml scanner drops comments as it tokenizes the input, so they
+// are recovered from the source lines using the marks of the events.
+type commentScanner struct {
+	src     *source
+	done    int  // The number of bytes of src split into lines so far.
+	partial bool // Whether the last line may still be incomplete.
+	first   int
+	lines   []string
+	claimed []bool
+}
+
+func newCommentScanner(src *source) *commentScanner {
+	return &commentScanner{src: src, first: src.first}
+}
+
+// text returns the given line of the source, reading in any text recorded
+// since the last call.
+func (s *commentScanner) text(line int) (string, bool) {
+	if line-s.first >= len(s.lines) {
+		s.sync()
+	}
+	i := line - s.first
+	if i < 0 || i >= len(s.lines) || s.claimed[i] {
+		return "", false
+	}
+	return s.lines[i], true
+}
+
+func (s *commentScanner) sync() {
+	if s.partial {
+		s.lines = s.lines[:len(s.lines)-1]
+		s.partial = false
+	}
+	data := s.src.buf[s.done:]
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			s.lines = append(s.lines, strings.TrimSuffix(string(data), "\r"))
+			s.partial = true
+			break
+		}
+		s.lines = append(s.lines, strings.TrimSuffix(string(data[:i]), "\r"))
+		s.done += i + 1
+		data = data[i+1:]
+	}
+	for len(s.claimed) < len(s.lines) {
+		s.claimed = append(s.claimed, false)
+	}
+}
+
+// end returns the number of the line following the last one read.
+func (s *commentScanner) end() int {
+	s.sync()
+	return s.first + len(s.lines)
+}
+
+// comment returns the comment held by the given line, if the line holds
+// nothing but a comment.
+func (s *commentScanner) comment(line int) (text string, column int, ok bool) {
+	l, ok := s.text(line)
+	trimmed := strings.TrimLeft(l, " \t")
+	if !ok || !strings.HasPrefix(trimmed, "#") {
+		return "", 0, false
+	}
+	return trimmed, len(l) - len(trimmed), true
+}
+
+func (s *commentScanner) blank(line int) bool {
+	l, ok := s.text(line)
+	return ok && strings.TrimSpace(l) == ""
+}
+
+func (s *commentScanner) claimLine(line int) {
+	s.claimed[line-s.first] = true
+}
+
+// above claims the comment lines immediately preceding line that are
+// indented at least as far as column, stopping at the first line holding
+// anything else.
+func (s *commentScanner) above(line, column int) string {
+	first := line
+	for first > s.first {
+		if _, col, ok := s.comment(first - 1); !ok || col < column {
+			break
+		}
+		first--
+	}
+	return s.claim(first, line)
+}
+
+// after returns the comment trailing the given position on the same line.
+func (s *commentScanner) after(line, column int) string {
+	l, ok := s.text(line)
+	if !ok {
+		return ""
+	}
+	i := byteOffset(l, column)
+	rest := strings.TrimLeft(l[i:], " \t")
+	if strings.HasPrefix(rest, ":") {
+		rest = strings.TrimLeft(rest[1:], " \t")
+	}
+	if !strings.HasPrefix(rest, "#") {
+		return ""
+	}
+	s.claimLine(line)
+	return rest
+}
+
+// footers claims the comments that follow the last entry of each block
+// collection in n, up to the end line, as foot comments of that entry.
+func (s *commentScanner) footers(n *Node, end int) {
+	if n.Style&FlowStyle != 0 || len(n.Content) == 0 {
+		return
+	}
+	switch n.Kind {
+	case MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			next := end
+			if i+2 < len(n.Content) {
+				next = n.Content[i+2].Line - 1
+				if n.Content[i+2].HeadComment != "" {
+					next -= strings.Count(n.Content[i+2].HeadComment, "\n") + 1
+				}
+			}
+			s.footers(n.Content[i+1], next)
+			n.Content[i].FootComment = s.below(n.Content[i+1], next, n.Column-1)
+		}
+	case SequenceNode:
+		for i, item := range n.Content {
+			next := end
+			if i+1 < len(n.Content) {
+				next = n.Content[i+1].Line - 1
+				if n.Content[i+1].HeadComment != "" {
+					next -= strings.Count(n.Content[i+1].HeadComment, "\n") + 1
+				}
+			}
+			s.footers(item, next)
+			item.FootComment = s.below(item, next, n.Column-1)
+		}
+	}
+}
+
+// below claims the comment lines between node n and the end line that are
+// indented deeper than column, ignoring any empty lines right before end.
+func (s *commentScanner) below(n *Node, end, column int) string {
+	last := end
+	for last-1 >= n.Line && s.blank(last-1) {
+		last--
+	}
+	first := last
+	for first-1 >= n.Line {
+		if _, col, ok := s.comment(first - 1); !ok || col <= column {
+			break
+		}
+		first--
+	}
+	return s.claim(first, last)
+}
+
+// block records that the lines in [first, last) hold the text of a block
+// scalar, so that those starting with "#" are not taken for comments.
+func (s *commentScanner) block(first, last int) {
+	if last-s.first > len(s.lines) {
+		s.sync()
+	}
+	for line := first; line < last && line-s.first < len(s.lines); line++ {
+		if line >= s.first {
+			s.claimLine(line)
+		}
+	}
+}
+
+// remaining claims every comment left unclaimed before the end line.
+func (s *commentScanner) remaining(end int) string {
+	var comments []string
+	for line := s.first; line < end; line++ {
+		if text, _, ok := s.comment(line); ok {
+			comments = append(comments, text)
+			s.claimLine(line)
+		}
+	}
+	return strings.Join(comments, "\n")
+}
+
+// claim marks the comment lines in [first, last) as used and returns them,
+// skipping the lines already claimed, such as those of block scalars.
+func (s *commentScanner) claim(first, last int) string {
+	var comments []string
+	for line := first; line < last; line++ {
+		text, _, ok := s.comment(line)
+		if !ok {
+			continue
+		}
+		comments = append(comments, text)
+		s.claimLine(line)
+	}
+	return strings.Join(comments, "\n")
+}
+
+// byteOffset converts a column counted in characters into a byte offset.
+func byteOffset(line string, column int) int {
+	i := 0
+	for ; column > 0 && i < len(line); column-- {
+		_, w := utf8.DecodeRuneInString(line[i:])
+		i += w
+	}
+	return i
+}
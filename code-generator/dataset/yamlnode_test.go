@@ -0,0 +1,158 @@
+package yaml_test
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+	"gopkg.in/yaml.v2"
+)
+
+var nodeRoundTripTests = []string{
+	"a: 1\nb: two\n",
+	"# Head of the document.\n\n# Head of a.\na: 1 # Line of a.\n# Head of b.\nb:\n  c: 2\n  # Foot of c.\n",
+	"list:\n- a\n- 'b'\n- \"c\\td\"\n",
+	"seq:\n- a: 1\n  b: 2\n- - x\n  - y\n",
+	"flow: {a: 1, b: [x, y]} # Line of flow.\n",
+	"text: |\n  one\n  two\nfolded: >-\n  one\n\n  two\n",
+	"base: &base\n  a: 1\nderived:\n  <<: *base\n  b: 2\n",
+	"tagged: !!str 123\nbinary: !!binary aGVsbG8=\nempty:\n",
+	"- one\n# Head of two.\n- two\n# Foot of the document.\n",
+	"script: |\n  # not a comment\n  echo hi\n# Head of b.\nb: 1\n",
+	"- >\n  # folded\n\n  # text\n- |+\n  # kept\n\n# Foot of the document.\n",
+}
+
+func (s *S) TestNodeRoundTrip(c *C) {
+	for i, item := range nodeRoundTripTests {
+		c.Logf("test %d: %q", i, item)
+		var n yaml.Node
+		err := yaml.Unmarshal([]byte(item), &n)
+		c.Assert(err, IsNil)
+		data, err := yaml.Marshal(&n)
+		c.Assert(err, IsNil)
+		c.Assert(string(data), Equals, item)
+	}
+}
+
+func (s *S) TestNodeComments(c *C) {
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte("# Head.\na: 1 # Line.\n  # Foot.\n"), &n)
+	c.Assert(err, IsNil)
+	c.Assert(n.Kind, Equals, yaml.DocumentNode)
+	m := n.Content[0]
+	c.Assert(m.Kind, Equals, yaml.MappingNode)
+	c.Assert(m.Content[0].HeadComment, Equals, "# Head.")
+	c.Assert(m.Content[0].FootComment, Equals, "# Foot.")
+	c.Assert(m.Content[1].LineComment, Equals, "# Line.")
+	c.Assert(m.Content[1].Line, Equals, 2)
+	c.Assert(m.Content[1].Column, Equals, 4)
+}
+
+func (s *S) TestNodeBlockScalarComments(c *C) {
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte("script: |\n  # not a comment\n  echo hi\nb: 1\n"), &n)
+	c.Assert(err, IsNil)
+	m := n.Content[0]
+	c.Assert(m.Content[1].Value, Equals, "# not a comment\necho hi\n")
+	c.Assert(m.Content[2].HeadComment, Equals, "")
+	c.Assert(m.Content[0].FootComment, Equals, "")
+	c.Assert(n.FootComment, Equals, "")
+}
+
+func (s *S) TestNodeEdit(c *C) {
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte("# Release settings.\nname: app\nversion: '1.2.3' # Bumped by CI.\n"), &n)
+	c.Assert(err, IsNil)
+	n.Content[0].Content[3].Value = "1.2.4"
+	data, err := yaml.Marshal(&n)
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "# Release settings.\nname: app\nversion: '1.2.4' # Bumped by CI.\n")
+}
+
+func (s *S) TestNodeStyles(c *C) {
+	n := &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{
+		{Kind: yaml.ScalarNode, Value: "plain"},
+		{Kind: yaml.ScalarNode, Tag: "!!str", Value: "true"},
+		{Kind: yaml.ScalarNode, Value: "quoted"},
+		{Kind: yaml.ScalarNode, Style: yaml.SingleQuotedStyle, Value: "it's"},
+		{Kind: yaml.ScalarNode, Value: "number"},
+		{Kind: yaml.ScalarNode, Tag: "!!int", Value: "42"},
+		{Kind: yaml.ScalarNode, Value: "flow"},
+		{Kind: yaml.SequenceNode, Style: yaml.FlowStyle, Content: []*yaml.Node{
+			{Kind: yaml.ScalarNode, Value: "a,b"},
+		}},
+	}}
+	data, err := yaml.Marshal(n)
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "plain: \"true\"\nquoted: 'it''s'\nnumber: 42\nflow: [\"a,b\"]\n")
+}
+
+func (s *S) TestNodeDecode(c *C) {
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte("a: &x [1, 2]\nb: *x\nc: !!str 3\n"), &n)
+	c.Assert(err, IsNil)
+	var v struct {
+		A, B []int
+		C    string
+	}
+	err = n.Decode(&v)
+	c.Assert(err, IsNil)
+	c.Assert(v.A, DeepEquals, []int{1, 2})
+	c.Assert(v.B, DeepEquals, []int{1, 2})
+	c.Assert(v.C, Equals, "3")
+
+	var m map[string]int
+	err = n.Content[0].Decode(&m)
+	c.Assert(err, ErrorMatches, "(?s)yaml: unmarshal errors:.*line 3, column 4: cannot unmarshal !!str `3` into int")
+}
+
+func (s *S) TestNodeNested(c *C) {
+	var v struct {
+		Name  string
+		Spec  yaml.Node
+		Items []*yaml.Node
+		None  yaml.Node
+	}
+	err := yaml.Unmarshal([]byte("name: app\nspec: {replicas: 2}\nitems: [a, 1]\n"), &v)
+	c.Assert(err, IsNil)
+	c.Assert(v.Name, Equals, "app")
+	c.Assert(v.Spec.Kind, Equals, yaml.MappingNode)
+	c.Assert(v.Spec.Line, Equals, 2)
+	c.Assert(v.Spec.Content[1].Value, Equals, "2")
+	c.Assert(v.Items, HasLen, 2)
+	c.Assert(v.Items[1].Tag, Equals, "!!int")
+
+	v.Spec.Content[1].Value = "3"
+	data, err := yaml.Marshal(v)
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "name: app\nspec: {replicas: 3}\nitems:\n- a\n- 1\nnone: null\n")
+
+	// As does a Node decoded from a Node.
+	var n yaml.Node
+	err = yaml.Unmarshal(data, &n)
+	c.Assert(err, IsNil)
+	err = n.Decode(&v)
+	c.Assert(err, IsNil)
+	c.Assert(v.Spec.Content[1].Value, Equals, "3")
+}
+
+func (s *S) TestNodeEncode(c *C) {
+	var n yaml.Node
+	err := n.Encode(map[string]interface{}{"a": 1, "b": []string{"x"}})
+	c.Assert(err, IsNil)
+	c.Assert(n.Kind, Equals, yaml.MappingNode)
+	c.Assert(n.Content[0].Value, Equals, "a")
+	c.Assert(n.Content[1].Tag, Equals, "!!int")
+	c.Assert(n.Content[3].Kind, Equals, yaml.SequenceNode)
+
+	// The scalars are styled as the encoder writes them.
+	err = n.Encode([]interface{}{"true", "a\nb", "\xff", 1.5, time.Second, nil})
+	c.Assert(err, IsNil)
+	c.Assert(n.Content, DeepEquals, []*yaml.Node{
+		{Kind: yaml.ScalarNode, Tag: "!!str", Value: "true", Style: yaml.DoubleQuotedStyle},
+		{Kind: yaml.ScalarNode, Tag: "!!str", Value: "a\nb", Style: yaml.LiteralStyle},
+		{Kind: yaml.ScalarNode, Tag: "!!binary", Value: "/w==", Style: yaml.TaggedStyle},
+		{Kind: yaml.ScalarNode, Tag: "!!float", Value: "1.5"},
+		{Kind: yaml.ScalarNode, Tag: "!!str", Value: "1s"},
+		{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"},
+	})
+}
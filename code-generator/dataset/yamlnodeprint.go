@@ -0,0 +1,439 @@
+package yaml
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// nodePrinter renders Node trees as YAML text. Unlike the emitter, which
+// drives the output from a stream of events and has no notion of comments,
+// the printer walks the tree directly so that the comments and styles held
+// by each node can be written back where they belong.
+type nodePrinter struct {
+	out    []byte
+	indent int
+}
+
+func newNodePrinter() *nodePrinter {
+	return &nodePrinter{indent: 2}
+}
+
+// document writes n as a complete document, preceded by a document start
+// marker if explicit is set. The zero Node stands for an empty document.
+func (p *nodePrinter) document(n *Node, explicit bool) {
+	if n.Kind == 0 {
+		return
+	}
+	if n.Kind != DocumentNode {
+		n = &Node{Kind: DocumentNode, Content: []*Node{n}}
+	}
+	if len(n.Content) > 0 && p.properties(n.Content[0]) != "" && isBlockCollection(n.Content[0]) {
+		explicit = true
+	}
+	p.newline()
+	if explicit {
+		p.write("---")
+	}
+	if n.HeadComment != "" {
+		p.comment(n.HeadComment, 0)
+		p.write("\n")
+	}
+	for _, c := range n.Content {
+		if c.Kind == ScalarNode {
+			p.node(c, p.indent, false)
+		} else {
+			p.node(c, 0, false)
+		}
+	}
+	p.newline()
+	p.comment(n.FootComment, 0)
+}
+
+// node writes n in block context. The output is either at the start of a
+// line, right after a "key:" indicator, or right after a "-" or "?"
+// indicator when compact is set, in which case a nested collection may
+// start in the same line. The indent is the column where the entries of a
+// nested collection, or the lines of a block scalar, must be written.
+func (p *nodePrinter) node(n *Node, indent int, compact bool) {
+	switch {
+	case n.Kind == ScalarNode:
+		p.space()
+		p.scalar(n, indent, false)
+	case n.Kind == AliasNode || !isBlockCollection(n):
+		p.space()
+		p.flow(n)
+		p.lineComment(n.LineComment)
+	case n.Kind == MappingNode:
+		if props := p.properties(n); props != "" {
+			p.space()
+			p.write(props)
+			compact = false
+		}
+		if n.LineComment != "" {
+			p.lineComment(n.LineComment)
+			compact = false
+		}
+		p.mapping(n, indent, compact)
+	case n.Kind == SequenceNode:
+		if props := p.properties(n); props != "" {
+			p.space()
+			p.write(props)
+			compact = false
+		}
+		if n.LineComment != "" {
+			p.lineComment(n.LineComment)
+			compact = false
+		}
+		p.sequence(n, indent, compact)
+	default:
+		failf("cannot encode node with unknown kind %d", n.Kind)
+	}
+}
+
+func (p *nodePrinter) mapping(n *Node, indent int, compact bool) {
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		k, v := n.Content[i], n.Content[i+1]
+		if i > 0 || !compact || k.HeadComment != "" {
+			p.comment(k.HeadComment, indent)
+			p.line(indent)
+		} else {
+			p.space()
+		}
+		if isSimpleKey(k) {
+			if k.Kind == AliasNode {
+				p.flow(k)
+				p.write(" ")
+			} else {
+				p.scalar(&Node{Kind: ScalarNode, Style: k.Style, Tag: k.Tag, Value: k.Value, Anchor: k.Anchor}, indent, true)
+			}
+			p.write(":")
+		} else {
+			p.write("?")
+			p.node(k, indent+2, true)
+			p.line(indent)
+			p.write(":")
+		}
+		if isBlockCollection(v) {
+			p.lineComment(k.LineComment)
+		}
+		child := indent + p.indent
+		if v.Kind == SequenceNode && isBlockCollection(v) {
+			child = indent
+		}
+		p.node(v, child, false)
+		if !isBlockCollection(v) {
+			p.lineComment(k.LineComment)
+		}
+		if v.Kind == ScalarNode && v.Style&(LiteralStyle|FoldedStyle) != 0 {
+			p.comment(k.FootComment, indent)
+		} else {
+			p.comment(k.FootComment, indent+p.indent)
+		}
+	}
+}
+
+func (p *nodePrinter) sequence(n *Node, indent int, compact bool) {
+	for i, item := range n.Content {
+		if i > 0 || !compact || item.HeadComment != "" {
+			p.comment(item.HeadComment, indent)
+			p.line(indent)
+		} else {
+			p.space()
+		}
+		p.write("-")
+		p.node(item, indent+2, true)
+		p.comment(item.FootComment, indent+2)
+	}
+}
+
+// flow writes n in flow context, where comments cannot be represented
+// other than at the end of the enclosing line.
+func (p *nodePrinter) flow(n *Node) {
+	switch n.Kind {
+	case AliasNode:
+		anchor := n.Value
+		if n.Alias != nil && n.Alias.Anchor != "" {
+			anchor = n.Alias.Anchor
+		}
+		p.write("*" + anchor)
+		return
+	case ScalarNode:
+		p.scalar(n, 0, true)
+		return
+	}
+	if props := p.properties(n); props != "" {
+		p.write(props + " ")
+	}
+	open, close := "[", "]"
+	if n.Kind == MappingNode {
+		open, close = "{", "}"
+	}
+	p.write(open)
+	for i, c := range n.Content {
+		switch {
+		case i == 0:
+		case n.Kind == MappingNode && i%2 == 1:
+			p.write(": ")
+		default:
+			p.write(", ")
+		}
+		p.flow(c)
+	}
+	p.write(close)
+}
+
+// properties returns the anchor and tag that must precede a collection.
+func (p *nodePrinter) properties(n *Node) string {
+	var props []string
+	if n.Anchor != "" {
+		props = append(props, "&"+n.Anchor)
+	}
+	tag := shortTag(n.Tag)
+	switch {
+	case n.Kind == MappingNode && (n.Style&TaggedStyle != 0 || tag != "" && tag != "!!map"):
+		props = append(props, n.ShortTag())
+	case n.Kind == SequenceNode && (n.Style&TaggedStyle != 0 || tag != "" && tag != "!!seq"):
+		props = append(props, n.ShortTag())
+	}
+	return strings.Join(props, " ")
+}
+
+// scalar writes the scalar n, picking the requested style when it is able
+// to represent the value and tag, and falling back to a quoted one when
+// it is not. In flow context, and for keys, only single line styles are
+// used.
+func (p *nodePrinter) scalar(n *Node, indent int, flow bool) {
+	tag := shortTag(n.Tag)
+	tagged := n.Style&TaggedStyle != 0
+	value := n.Value
+
+	var style yaml_scalar_style_t
+	switch {
+	case n.Style&(LiteralStyle|FoldedStyle) != 0 && !flow && isBlockScalarAllowed(value):
+		if n.Style&LiteralStyle != 0 {
+			style = yaml_LITERAL_SCALAR_STYLE
+		} else {
+			style = yaml_FOLDED_SCALAR_STYLE
+		}
+	case n.Style&SingleQuotedStyle != 0 && isSingleQuotedAllowed(value):
+		style = yaml_SINGLE_QUOTED_SCALAR_STYLE
+	case n.Style&(DoubleQuotedStyle|SingleQuotedStyle|LiteralStyle|FoldedStyle) != 0:
+		style = yaml_DOUBLE_QUOTED_SCALAR_STYLE
+	case isPlainAllowed(value, flow, tag):
+		style = yaml_PLAIN_SCALAR_STYLE
+		if rtag, _ := resolve("", value); tag != "" && !tagged && shortTag(rtag) != tag {
+			if tag == "!!str" {
+				style = yaml_DOUBLE_QUOTED_SCALAR_STYLE
+			} else {
+				tagged = true
+			}
+		}
+	default:
+		style = yaml_DOUBLE_QUOTED_SCALAR_STYLE
+	}
+	if style != yaml_PLAIN_SCALAR_STYLE && tag != "" && tag != "!!str" {
+		tagged = true
+	}
+	if tagged && tag == "" {
+		tag = n.ShortTag()
+	}
+
+	var props []string
+	if n.Anchor != "" {
+		props = append(props, "&"+n.Anchor)
+	}
+	if tagged {
+		props = append(props, tag)
+	}
+	if len(props) > 0 {
+		p.write(strings.Join(props, " "))
+		if value != "" || style != yaml_PLAIN_SCALAR_STYLE {
+			p.write(" ")
+		}
+	}
+
+	switch style {
+	case yaml_PLAIN_SCALAR_STYLE:
+		p.write(value)
+	case yaml_SINGLE_QUOTED_SCALAR_STYLE:
+		p.write("'" + strings.Replace(value, "'", "''", -1) + "'")
+	case yaml_DOUBLE_QUOTED_SCALAR_STYLE:
+		p.write(strconv.Quote(value))
+	case yaml_LITERAL_SCALAR_STYLE, yaml_FOLDED_SCALAR_STYLE:
+		p.blockScalar(value, indent, style == yaml_FOLDED_SCALAR_STYLE, n.LineComment)
+		return
+	}
+	if !flow {
+		p.lineComment(n.LineComment)
+	}
+}
+
+// blockScalar writes value as a literal or folded block scalar whose lines
+// are indented at the given column.
+func (p *nodePrinter) blockScalar(value string, indent int, folded bool, comment string) {
+	body := value
+	chomp := ""
+	switch {
+	case !strings.HasSuffix(value, "\n"):
+		chomp = "-"
+	case strings.HasSuffix(value, "\n\n"):
+		chomp = "+"
+		body = value[:len(value)-1]
+	default:
+		body = value[:len(value)-1]
+	}
+	if folded {
+		p.write(">" + chomp)
+	} else {
+		p.write("|" + chomp)
+	}
+	p.lineComment(comment)
+	p.write("\n")
+	prev := ""
+	for _, line := range strings.Split(body, "\n") {
+		if folded && line != "" && prev != "" && !isIndentedLine(prev) && !isIndentedLine(line) {
+			// A single line break between two lines is folded into a
+			// space, so it must be kept apart by an empty line instead.
+			p.write("\n")
+		}
+		if line != "" {
+			p.pad(indent)
+			p.write(line)
+			prev = line
+		}
+		p.write("\n")
+	}
+}
+
+// comment writes each line of text as a comment in its own line.
+func (p *nodePrinter) comment(text string, indent int) {
+	if text == "" {
+		return
+	}
+	for _, line := range strings.Split(text, "\n") {
+		p.line(indent)
+		p.write(commentLine(line))
+		p.write("\n")
+	}
+}
+
+// lineComment appends text as a comment to the current line.
+func (p *nodePrinter) lineComment(text string) {
+	if text != "" {
+		p.write(" " + commentLine(text))
+	}
+}
+
+func (p *nodePrinter) write(s string) {
+	p.out = append(p.out, s...)
+}
+
+func (p *nodePrinter) pad(indent int) {
+	for i := 0; i < indent; i++ {
+		p.out = append(p.out, ' ')
+	}
+}
+
+// space separates the upcoming content from the indicator preceding it.
+func (p *nodePrinter) space() {
+	if len(p.out) > 0 && p.out[len(p.out)-1] != '\n' && p.out[len(p.out)-1] != ' ' {
+		p.out = append(p.out, ' ')
+	}
+}
+
+// newline terminates the current line, if any, dropping trailing spaces.
+func (p *nodePrinter) newline() {
+	if len(p.out) == 0 || p.out[len(p.out)-1] == '\n' {
+		return
+	}
+	for len(p.out) > 0 && p.out[len(p.out)-1] == ' ' {
+		p.out = p.out[:len(p.out)-1]
+	}
+	p.out = append(p.out, '\n')
+}
+
+// line starts a new line indented at the given column.
+func (p *nodePrinter) line(indent int) {
+	p.newline()
+	p.pad(indent)
+}
+
+func commentLine(text string) string {
+	if strings.HasPrefix(text, "#") {
+		return text
+	}
+	return "# " + text
+}
+
+func isBlockCollection(n *Node) bool {
+	return (n.Kind == MappingNode || n.Kind == SequenceNode) && n.Style&FlowStyle == 0 && len(n.Content) > 0
+}
+
+func isSimpleKey(n *Node) bool {
+	switch n.Kind {
+	case AliasNode:
+		return true
+	case ScalarNode:
+		return len(n.Value) <= 1024 && isSingleQuotedAllowed(n.Value)
+	}
+	return false
+}
+
+func isIndentedLine(line string) bool {
+	return strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")
+}
+
+// isPlainAllowed reports whether value may be written as a plain scalar
+// without changing its meaning.
+func isPlainAllowed(value string, flow bool, tag string) bool {
+	if value == "" {
+		return tag == "!!null"
+	}
+	if value != strings.TrimSpace(value) || strings.HasPrefix(value, "---") || strings.HasPrefix(value, "...") {
+		return false
+	}
+	switch value[0] {
+	case ',', '[', ']', '{', '}', '#', '&', '*', '!', '|', '>', '\'', '"', '%', '@', '`':
+		return false
+	case '-', '?', ':':
+		if len(value) == 1 || value[1] == ' ' {
+			return false
+		}
+	}
+	if strings.HasSuffix(value, ":") || strings.Contains(value, ": ") || strings.Contains(value, " #") {
+		return false
+	}
+	if flow && strings.ContainsAny(value, ",[]{}") {
+		return false
+	}
+	for _, r := range value {
+		if r != ' ' && !unicode.IsPrint(r) || r == '\uFEFF' {
+			return false
+		}
+	}
+	return true
+}
+
+func isSingleQuotedAllowed(value string) bool {
+	for _, r := range value {
+		if r != ' ' && !unicode.IsPrint(r) || r == '\uFEFF' {
+			return false
+		}
+	}
+	return true
+}
+
+// isBlockScalarAllowed reports whether value may be written as a literal or
+// folded scalar, whose indentation is detected from its first line.
+func isBlockScalarAllowed(value string) bool {
+	if value == "" || isIndentedLine(value) || strings.HasPrefix(value, "\n") {
+		return false
+	}
+	for _, r := range value {
+		if r != '\n' && r != '\t' && r != ' ' && !unicode.IsPrint(r) || r == '\uFEFF' {
+			return false
+		}
+	}
+	return true
+}
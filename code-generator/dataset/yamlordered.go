@@ -0,0 +1,178 @@
+package yaml
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var mapSliceType = reflect.TypeOf(MapSlice{})
+
+// valueNode converts in into a node tree, holding what the encoder would
+// write for it, except that it is built out of the nodes returned by
+// NodeMarshalers and with the items of the ,inline MapSlice fields of
+// structs written after the other fields of the struct.
+func valueNode(in reflect.Value) *Node {
+	if !in.IsValid() {
+		return nullNode()
+	}
+	switch m := in.Interface().(type) {
+	case NodeMarshaler:
+		if in.Kind() == reflect.Ptr && in.IsNil() {
+			return nullNode()
+		}
+		n, err := m.MarshalYAMLNode()
+		if err != nil {
+			fail(err)
+		}
+		if n == nil {
+			return nullNode()
+		}
+		return n
+	case Marshaler:
+		v, err := m.MarshalYAML()
+		if err != nil {
+			fail(err)
+		}
+		if v == nil {
+			return nullNode()
+		}
+		in = reflect.ValueOf(v)
+	case encoding.TextMarshaler:
+		text, err := m.MarshalText()
+		if err != nil {
+			fail(err)
+		}
+		in = reflect.ValueOf(string(text))
+	}
+	switch in.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		if in.IsNil() {
+			return nullNode()
+		}
+		return valueNode(in.Elem())
+	case reflect.Map:
+		n := &Node{Kind: MappingNode, Tag: "!!map"}
+		keys := keyList(in.MapKeys())
+		sort.Sort(keys)
+		for _, k := range keys {
+			n.Content = append(n.Content, valueNode(k), valueNode(in.MapIndex(k)))
+		}
+		return n
+	case reflect.Struct:
+		return structNode(in)
+	case reflect.Slice, reflect.Array:
+		if in.Type().Elem() == mapItemType {
+			return itemsNode(in.Convert(mapSliceType).Interface().(MapSlice))
+		}
+		n := &Node{Kind: SequenceNode, Tag: "!!seq"}
+		for i := 0; i < in.Len(); i++ {
+			n.Content = append(n.Content, valueNode(in.Index(i)))
+		}
+		return n
+	case reflect.String:
+		return stringNode(in.String())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if in.Type() == durationType {
+			return stringNode(time.Duration(in.Int()).String())
+		}
+		return plainNode(strconv.FormatInt(in.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return plainNode(strconv.FormatUint(in.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		// As the encoder does, with 32 bits.
+		s := strconv.FormatFloat(in.Float(), 'g', -1, 32)
+		switch s {
+		case "+Inf":
+			s = ".inf"
+		case "-Inf":
+			s = "-.inf"
+		case "NaN":
+			s = ".nan"
+		}
+		return plainNode(s)
+	case reflect.Bool:
+		return plainNode(strconv.FormatBool(in.Bool()))
+	}
+	panic("cannot marshal type: " + in.Type().String())
+}
+
+func structNode(in reflect.Value) *Node {
+	sinfo, err := getStructInfo(in.Type())
+	if err != nil {
+		panic(err)
+	}
+	n := &Node{Kind: MappingNode, Tag: "!!map"}
+	for _, info := range sinfo.FieldsList {
+		var value reflect.Value
+		if info.Inline == nil {
+			value = in.Field(info.Num)
+		} else {
+			value = in.FieldByIndex(info.Inline)
+		}
+		if info.OmitEmpty && isZero(value) {
+			continue
+		}
+		v := valueNode(value)
+		if info.Flow && (v.Kind == MappingNode || v.Kind == SequenceNode) {
+			v.Style |= FlowStyle
+		}
+		n.Content = append(n.Content, stringNode(info.Key), v)
+	}
+	if sinfo.InlineMap >= 0 {
+		m := in.Field(sinfo.InlineMap)
+		keys := keyList(m.MapKeys())
+		sort.Sort(keys)
+		for _, k := range keys {
+			if _, found := sinfo.FieldsMap[k.String()]; found {
+				panic(fmt.Sprintf("Can't have key %q in inlined map; conflicts with struct field", k.String()))
+			}
+			n.Content = append(n.Content, valueNode(k), valueNode(m.MapIndex(k)))
+		}
+	}
+	if sinfo.InlineSlice >= 0 {
+		items := itemsNode(in.Field(sinfo.InlineSlice).Convert(mapSliceType).Interface().(MapSlice))
+		n.Content = append(n.Content, items.Content...)
+	}
+	return n
+}
+
+func itemsNode(items MapSlice) *Node {
+	n := &Node{Kind: MappingNode, Tag: "!!map"}
+	for _, item := range items {
+		n.Content = append(n.Content, valueNode(reflect.ValueOf(item.Key)), valueNode(reflect.ValueOf(item.Value)))
+	}
+	return n
+}
+
+// stringNode returns the node of s, in the style the encoder writes it
+// with: quoted if it would be read back as another type, and literal if
+// it spans several lines. Data which is not valid UTF-8 is tagged as
+// binary, and encoded in base64.
+func stringNode(s string) *Node {
+	rtag, rs := resolve("", s)
+	switch {
+	case rtag == yaml_BINARY_TAG:
+		return &Node{Kind: ScalarNode, Tag: "!!binary", Value: rs.(string), Style: TaggedStyle}
+	case rtag != yaml_STR_TAG || isBase60Float(s):
+		return &Node{Kind: ScalarNode, Tag: "!!str", Value: s, Style: DoubleQuotedStyle}
+	case strings.Contains(s, "\n"):
+		return &Node{Kind: ScalarNode, Tag: "!!str", Value: s, Style: LiteralStyle}
+	}
+	return &Node{Kind: ScalarNode, Tag: "!!str", Value: s}
+}
+
+// plainNode returns the node of the plain scalar s, with the tag it
+// resolves to.
+func plainNode(s string) *Node {
+	tag, _ := resolve("", s)
+	return &Node{Kind: ScalarNode, Tag: shortTag(tag), Value: s}
+}
+
+func nullNode() *Node {
+	return &Node{Kind: ScalarNode, Tag: "!!null", Value: "null"}
+}
@@ -0,0 +1,121 @@
+package yaml_test
+
+import (
+	"strings"
+
+	. "gopkg.in/check.v1"
+	"gopkg.in/yaml.v2"
+)
+
+func (s *S) TestDecoderOrdered(c *C) {
+	data := "z: 1\na:\n  w: [{c: 1, b: 2}]\n  v: 2\nm: {q: 1, p: 2}\n"
+	dec := yaml.NewDecoder(strings.NewReader(data))
+	dec.SetOrdered(true)
+	var v interface{}
+	err := dec.Decode(&v)
+	c.Assert(err, IsNil)
+	c.Assert(v, DeepEquals, yaml.MapSlice{
+		{"z", 1},
+		{"a", yaml.MapSlice{
+			{"w", []interface{}{yaml.MapSlice{{"c", 1}, {"b", 2}}}},
+			{"v", 2},
+		}},
+		{"m", yaml.MapSlice{{"q", 1}, {"p", 2}}},
+	})
+
+	// Round-tripping keeps the authored order.
+	out, err := yaml.Marshal(v)
+	c.Assert(err, IsNil)
+	c.Assert(string(out), Equals, "z: 1\na:\n  w:\n  - c: 1\n    b: 2\n  v: 2\nm:\n  q: 1\n  p: 2\n")
+
+	// Typed values are decoded as usual, with ordered mappings within.
+	dec = yaml.NewDecoder(strings.NewReader(data))
+	dec.SetOrdered(true)
+	var m map[string]interface{}
+	err = dec.Decode(&m)
+	c.Assert(err, IsNil)
+	c.Assert(m["m"], DeepEquals, yaml.MapSlice{{"q", 1}, {"p", 2}})
+
+	// Unmarshal decodes in order with the Ordered option.
+	v = nil
+	err = yaml.UnmarshalWithOptions([]byte(data), &v, yaml.UnmarshalOptions{Ordered: true})
+	c.Assert(err, IsNil)
+	c.Assert(v.(yaml.MapSlice)[0], DeepEquals, yaml.MapItem{"z", 1})
+}
+
+type inlineConfig struct {
+	Name  string
+	Extra yaml.MapSlice `yaml:",inline"`
+}
+
+type inlineOuter struct {
+	Services []inlineConfig
+	ByName   map[string]*inlineConfig
+}
+
+func (s *S) TestInlineMapSlice(c *C) {
+	data := "name: web\nzeta: 1\nalpha:\n  d: x\n  c: z\nmid: [1, 2]\n"
+	var v inlineConfig
+	err := yaml.Unmarshal([]byte(data), &v)
+	c.Assert(err, IsNil)
+	c.Assert(v, DeepEquals, inlineConfig{
+		Name: "web",
+		Extra: yaml.MapSlice{
+			{"zeta", 1},
+			{"alpha", yaml.MapSlice{{"d", "x"}, {"c", "z"}}},
+			{"mid", []interface{}{1, 2}},
+		},
+	})
+	out, err := yaml.Marshal(&v)
+	c.Assert(err, IsNil)
+	c.Assert(string(out), Equals, "name: web\nzeta: 1\nalpha:\n  d: x\n  c: z\nmid:\n- 1\n- 2\n")
+
+	// Strict decoding accepts the inlined keys.
+	err = yaml.UnmarshalStrict([]byte(data), &v)
+	c.Assert(err, IsNil)
+
+	// The merged keys are added to those found before them.
+	v = inlineConfig{}
+	err = yaml.Unmarshal([]byte("k1: 1\n<<: {k2: 2, k1: 3}\n"), &v)
+	c.Assert(err, IsNil)
+	c.Assert(v.Extra, DeepEquals, yaml.MapSlice{{"k1", 3}, {"k2", 2}})
+}
+
+func (s *S) TestInlineMapSliceNested(c *C) {
+	data := "" +
+		"base: &base\n  name: b\n  k2: 2\n" +
+		"services:\n- name: s1\n  k1: 1\n- <<: *base\n  k3: 3\n  k2: 4\n" +
+		"byname:\n  x:\n    name: x\n    k: v\n"
+	var v struct {
+		Base     interface{}
+		Services []inlineConfig
+		ByName   map[string]*inlineConfig
+	}
+	err := yaml.Unmarshal([]byte(data), &v)
+	c.Assert(err, IsNil)
+	c.Assert(v.Services, DeepEquals, []inlineConfig{
+		{Name: "s1", Extra: yaml.MapSlice{{"k1", 1}}},
+		{Name: "b", Extra: yaml.MapSlice{{"k2", 4}, {"k3", 3}}},
+	})
+	c.Assert(v.ByName["x"], DeepEquals, &inlineConfig{Name: "x", Extra: yaml.MapSlice{{"k", "v"}}})
+
+	var n yaml.Node
+	err = n.Encode(inlineOuter{Services: v.Services})
+	c.Assert(err, IsNil)
+	out, err := yaml.Marshal(&n)
+	c.Assert(err, IsNil)
+	c.Assert(string(out), Equals, "services:\n- name: s1\n  k1: 1\n- name: b\n  k2: 4\n  k3: 3\nbyname: {}\n")
+}
+
+func (s *S) TestInlineMapSliceConflict(c *C) {
+	var v struct {
+		A yaml.MapSlice  `yaml:",inline"`
+		B map[string]int `yaml:",inline"`
+	}
+	c.Assert(func() { yaml.Unmarshal([]byte("a: 1\n"), &v) }, PanicMatches, "Multiple ,inline maps in struct .*")
+
+	var w struct {
+		A []int `yaml:",inline"`
+	}
+	c.Assert(func() { yaml.Unmarshal([]byte("a: 1\n"), &w) }, PanicMatches, "Option ,inline needs a struct value, a map or a MapSlice field in struct .*")
+}
@@ -0,0 +1,279 @@
+package yaml
+
+import (
+	"io"
+	"reflect"
+)
+
+// A Decoder reads and decodes YAML documents from an input stream.
+type Decoder struct {
+	parser   *parser
+	composer *composer
+	src      *source
+	limiter  *limiter
+	started  bool
+	strict   bool
+	ordered  bool
+	err      error // The error which stopped the decoding of the stream.
+}
+
+// NewDecoder returns a new decoder that reads from r.
+//
+// The decoder introduces its own buffering and may read
+// data from r beyond the YAML values requested.
+func NewDecoder(r io.Reader) *Decoder {
+	src := &source{r: r}
+	p := newParserFromReader(src)
+	l := &limiter{limits: DefaultLimits}
+	l.attach(p)
+	return &Decoder{parser: p, composer: newComposer(p, src), src: src, limiter: l}
+}
+
+// Decode reads the next YAML-encoded document from its input
+// and stores it in the value pointed to by v. It returns io.EOF
+// once the input holds no further documents.
+//
+// Only the document being decoded is held in memory, so streams of
+// any size may be processed one document at a time.
+//
+// See the documentation for Unmarshal for details about the
+// conversion of YAML into a Go value.
+//
+// Once decoding fails with an error other than a *TypeError or a
+// *LimitError, every later call returns that error.
+func (dec *Decoder) Decode(v interface{}) (err error) {
+	if dec.err != nil {
+		return dec.err
+	}
+	defer func() {
+		// Past a syntax error, or a document left half decoded, the
+		// stream cannot be read any further.
+		switch err.(type) {
+		case nil, *TypeError, *LimitError:
+		default:
+			if err != io.EOF {
+				dec.err = err
+			}
+		}
+	}()
+	defer handleErr(&err)
+	p := dec.parser
+	if !dec.started {
+		p.skip()
+		if p.event.typ != yaml_STREAM_START_EVENT {
+			failf("expected stream start event, got %d", p.event.typ)
+		}
+		p.skip()
+		dec.started = true
+	}
+	defer func() {
+		// Drop the text of the decoded document, which was only
+		// recorded to recover its comments.
+		dec.src.discard(p.event.start_mark.line)
+	}()
+	dec.limiter.start = p.event.start_mark.index
+	if n, ok := v.(*Node); ok {
+		var doc Node
+		if !dec.composer.document(&doc) {
+			return io.EOF
+		}
+		dec.limiter.checkSize(doc.Line-1, p.event.start_mark.index)
+		checkNodeDepth(&doc, dec.limiter.limits)
+		*n = doc
+		return nil
+	}
+	opts := decodeOptions{strict: dec.strict, ordered: dec.ordered, limits: dec.limiter.limits}
+	if rv := reflect.ValueOf(v); rv.IsValid() && typeHooks(rv.Type())&hookUnmarshaler != 0 {
+		// NodeUnmarshalers are handed the nodes they are decoded from.
+		var doc Node
+		opts.tree = dec.composer.track()
+		defer func() { dec.composer.marks = nil }()
+		if !dec.composer.document(&doc) {
+			return io.EOF
+		}
+		dec.limiter.checkSize(doc.Line-1, p.event.start_mark.index)
+		return decode(doc.internal(opts.tree), v, opts)
+	}
+	node := p.parse()
+	if node == nil {
+		return io.EOF
+	}
+	dec.limiter.checkSize(node.line, p.event.start_mark.index)
+	return decode(node, v, opts)
+}
+
+// SetStrict sets whether strict decoding behaviour is enabled when
+// decoding items in the data (see UnmarshalStrict). By default, decoding
+// is not strict.
+func (dec *Decoder) SetStrict(strict bool) {
+	dec.strict = strict
+}
+
+// SetOrdered sets whether mappings decoded into interface{} values are
+// decoded as a MapSlice, keeping their keys in the order they are found
+// in the document, rather than as a map. This applies at every level,
+// except within map[interface{}]interface{} values. By default, mappings
+// are decoded as map[interface{}]interface{}.
+func (dec *Decoder) SetOrdered(ordered bool) {
+	dec.ordered = ordered
+}
+
+// SetLimits sets the limits that each of the following documents must be
+// within to be decoded. By default, DefaultLimits are enforced. Once a
+// document exceeds the limits, the decoder fails with a *LimitError.
+func (dec *Decoder) SetLimits(limits Limits) {
+	dec.limiter.limits = limits
+}
+
+func newParserFromReader(r io.Reader) *parser {
+	p := parser{}
+	if !yaml_parser_initialize(&p.parser) {
+		panic("failed to initialize YAML parser")
+	}
+	p.parser.read_handler = yaml_file_read_handler
+	p.parser.input_file = r
+	return &p
+}
+
+// An Encoder writes YAML documents to an output stream.
+type Encoder struct {
+	w       io.Writer
+	encoder *encoder
+	docs    int
+	indent  int
+	width   int
+	flow    Kind
+	quote   bool
+}
+
+// NewEncoder returns a new encoder that writes to w.
+// The Encoder should be closed after use to flush all data
+// to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// Encode writes the YAML encoding of v to the stream.
+// If multiple items are encoded to the stream, the
+// second and subsequent document will be preceded
+// with a "---" document separator, but the first will not.
+//
+// Each document is written out as soon as it is complete.
+//
+// See the documentation for Marshal for details about the conversion of Go
+// values to YAML.
+func (e *Encoder) Encode(v interface{}) (err error) {
+	defer handleErr(&err)
+	switch n := v.(type) {
+	case Node:
+		return e.encodeNode(&n)
+	case *Node:
+		return e.encodeNode(n)
+	}
+	var n *Node
+	if rv := reflect.ValueOf(v); e.flow != 0 || e.quote || valueHooks(rv)&(hookInline|hookMarshaler) != 0 {
+		n = &Node{}
+		if err := n.Encode(v); err != nil {
+			return err
+		}
+		e.restyle(n)
+	}
+	if e.encoder == nil {
+		e.encoder = newEncoderWithWriter(e.w)
+	}
+	e.encoder.setLayout(e.indent, e.width)
+	e.encoder.must(yaml_document_start_event_initialize(&e.encoder.event, nil, nil, e.docs == 0))
+	e.encoder.emit()
+	if n != nil {
+		e.encoder.emitNode(n)
+	} else {
+		e.encoder.marshal("", reflect.ValueOf(v))
+	}
+	e.encoder.must(yaml_document_end_event_initialize(&e.encoder.event, true))
+	e.encoder.emit()
+	e.encoder.mustWrite()
+	e.docs++
+	return nil
+}
+
+func (e *Encoder) encodeNode(n *Node) error {
+	p := newNodePrinter()
+	if e.indent != 0 {
+		p.indent = e.indent
+	}
+	p.document(n, e.docs > 0)
+	if _, err := e.w.Write(p.out); err != nil {
+		return err
+	}
+	e.docs++
+	return nil
+}
+
+// SetIndent sets the number of spaces that nested collections are indented
+// with, which must be between 2 and 9. The default is 2.
+func (e *Encoder) SetIndent(spaces int) {
+	if spaces < 2 || spaces > 9 {
+		panic("yaml: indentation must be between 2 and 9 spaces")
+	}
+	e.indent = spaces
+}
+
+// SetWidth sets the preferred width of the output lines, past which long
+// strings are folded into multiple lines where they have spaces. A
+// negative width disables folding, and a zero width restores the default
+// of 80 columns. Node values are never folded.
+func (e *Encoder) SetWidth(width int) {
+	e.width = width
+}
+
+// SetFlowStyle sets the kinds of collections that are written in flow
+// style, such as [a, b] and {a: 1}, as a combination of SequenceNode and
+// MappingNode. Collections of the other kinds are written in block style,
+// unless their struct field has the flow flag. By default, all of them
+// are written in block style.
+func (e *Encoder) SetFlowStyle(kinds Kind) {
+	e.flow = kinds
+}
+
+// SetQuoteAmbiguous sets whether strings that other YAML parsers could
+// read as a different type of value are quoted. Such are the YAML 1.1
+// booleans in any letter case, like No or oN, timestamps, and YAML 1.2
+// octal numbers, like 0o17. Strings that this package itself would read
+// as a different type of value, like no, are always quoted.
+func (e *Encoder) SetQuoteAmbiguous(quote bool) {
+	e.quote = quote
+}
+
+// Close closes the encoder by writing any remaining data.
+// It does not write a stream terminating string "...".
+func (e *Encoder) Close() (err error) {
+	defer handleErr(&err)
+	if e.encoder == nil {
+		return nil
+	}
+	defer e.encoder.destroy()
+	e.encoder.emitter.open_ended = false
+	e.encoder.must(yaml_stream_end_event_initialize(&e.encoder.event))
+	e.encoder.emit()
+	e.encoder.mustWrite()
+	e.encoder = nil
+	return nil
+}
+
+func newEncoderWithWriter(w io.Writer) *encoder {
+	e := &encoder{}
+	e.must(yaml_emitter_initialize(&e.emitter))
+	yaml_emitter_set_output_file(&e.emitter, w)
+	yaml_emitter_set_unicode(&e.emitter, true)
+	e.must(yaml_stream_start_event_initialize(&e.event, yaml_UTF8_ENCODING))
+	e.emit()
+	return e
+}
+
+// mustWrite fails if flushing the emitter output to the writer failed,
+// which emit lets through for the events that end a document.
+func (e *encoder) mustWrite() {
+	if e.emitter.error == yaml_WRITER_ERROR {
+		e.must(false)
+	}
+}
@@ -0,0 +1,137 @@
+package yaml_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+
+	. "gopkg.in/check.v1"
+	"gopkg.in/yaml.v2"
+)
+
+func (s *S) TestDecoderMultipleDocuments(c *C) {
+	dec := yaml.NewDecoder(strings.NewReader("a: 1\n---\na: 2\n---\n- x\n"))
+	var v1, v2 map[string]int
+	c.Assert(dec.Decode(&v1), IsNil)
+	c.Assert(v1, DeepEquals, map[string]int{"a": 1})
+	c.Assert(dec.Decode(&v2), IsNil)
+	c.Assert(v2, DeepEquals, map[string]int{"a": 2})
+	var v3 []string
+	c.Assert(dec.Decode(&v3), IsNil)
+	c.Assert(v3, DeepEquals, []string{"x"})
+	c.Assert(dec.Decode(&v3), Equals, io.EOF)
+	c.Assert(dec.Decode(&v3), Equals, io.EOF)
+}
+
+func (s *S) TestDecoderEmptyInput(c *C) {
+	var v interface{}
+	c.Assert(yaml.NewDecoder(strings.NewReader("")).Decode(&v), Equals, io.EOF)
+	var n yaml.Node
+	c.Assert(yaml.NewDecoder(strings.NewReader("")).Decode(&n), Equals, io.EOF)
+}
+
+func (s *S) TestDecoderNodes(c *C) {
+	dec := yaml.NewDecoder(strings.NewReader("# First.\na: 1 # One.\n---\n# Second.\nb: 2\n"))
+	var n1, n2 yaml.Node
+	c.Assert(dec.Decode(&n1), IsNil)
+	c.Assert(dec.Decode(&n2), IsNil)
+	c.Assert(n1.Content[0].Content[0].HeadComment, Equals, "# First.")
+	c.Assert(n1.Content[0].Content[1].LineComment, Equals, "# One.")
+	c.Assert(n2.Content[0].Content[0].HeadComment, Equals, "# Second.")
+	c.Assert(n2.Content[0].Content[0].Line, Equals, 5)
+	c.Assert(dec.Decode(&n1), Equals, io.EOF)
+}
+
+func (s *S) TestDecoderLargeStream(c *C) {
+	var buf bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		buf.WriteString("---\nkind: item\nvalues: [1, 2, 3]\n")
+	}
+	dec := yaml.NewDecoder(&buf)
+	count := 0
+	for {
+		var v struct {
+			Kind   string
+			Values []int
+		}
+		err := dec.Decode(&v)
+		if err == io.EOF {
+			break
+		}
+		c.Assert(err, IsNil)
+		c.Assert(v.Kind, Equals, "item")
+		count++
+	}
+	c.Assert(count, Equals, 1000)
+}
+
+func (s *S) TestDecoderReadError(c *C) {
+	err := yaml.NewDecoder(errReader{}).Decode(&map[string]int{})
+	c.Assert(err, ErrorMatches, "yaml: input error: some read error")
+}
+
+func (s *S) TestDecoderSyntaxError(c *C) {
+	dec := yaml.NewDecoder(strings.NewReader("a: 1\n---\na: [1\n---\na: 3\n"))
+	var v map[string]interface{}
+	c.Assert(dec.Decode(&v), IsNil)
+	err := dec.Decode(&v)
+	c.Assert(err, ErrorMatches, "yaml: line .*")
+	c.Assert(dec.Decode(&v), Equals, err)
+	var n yaml.Node
+	c.Assert(dec.Decode(&n), Equals, err)
+
+	// Type errors leave the stream readable.
+	dec = yaml.NewDecoder(strings.NewReader("a: x\n---\na: 2\n"))
+	var m map[string]int
+	c.Assert(dec.Decode(&m), ErrorMatches, "yaml: unmarshal errors:\n.*")
+	c.Assert(dec.Decode(&m), IsNil)
+	c.Assert(m["a"], Equals, 2)
+}
+
+func (s *S) TestEncoderMultipleDocuments(c *C) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	c.Assert(enc.Encode(map[string]int{"a": 1}), IsNil)
+	c.Assert(enc.Encode(map[string]int{"b": 2}), IsNil)
+	c.Assert(enc.Close(), IsNil)
+	c.Assert(buf.String(), Equals, "a: 1\n---\nb: 2\n")
+}
+
+func (s *S) TestEncoderWritesEachDocument(c *C) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	c.Assert(enc.Encode([]int{1, 2}), IsNil)
+	c.Assert(buf.String(), Equals, "- 1\n- 2\n")
+	c.Assert(enc.Close(), IsNil)
+}
+
+func (s *S) TestEncoderNodes(c *C) {
+	var n yaml.Node
+	c.Assert(yaml.Unmarshal([]byte("# Comment.\na: 1\n"), &n), IsNil)
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	c.Assert(enc.Encode(&n), IsNil)
+	c.Assert(enc.Encode("text"), IsNil)
+	c.Assert(enc.Encode(&n), IsNil)
+	c.Assert(enc.Close(), IsNil)
+	c.Assert(buf.String(), Equals, "# Comment.\na: 1\n--- text\n---\n# Comment.\na: 1\n")
+}
+
+func (s *S) TestEncoderWriteError(c *C) {
+	enc := yaml.NewEncoder(errWriter{})
+	err := enc.Encode(map[string]int{"a": 1})
+	c.Assert(err, ErrorMatches, "yaml: write error: some write error")
+}
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) {
+	return 0, errors.New("some read error")
+}
+
+type errWriter struct{}
+
+func (errWriter) Write([]byte) (int, error) {
+	return 0, errors.New("some write error")
+}
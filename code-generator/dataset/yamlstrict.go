@@ -0,0 +1,155 @@
+package yaml
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// strictChecker walks a node tree along with the type it is about to be
+// decoded into, collecting the problems that strict decoding rejects:
+// mapping keys that are set more than once, and mapping keys that do not
+// correspond to any field of the struct they are decoded into.
+type strictChecker struct {
+	doc     *node
+	aliases map[*node]bool
+	terrors []string
+}
+
+// checkStrict returns the strict decoding problems found in n when
+// decoding it into a value of type t.
+func checkStrict(n *node, t reflect.Type) []string {
+	s := &strictChecker{aliases: make(map[*node]bool)}
+	s.check(n, t)
+	return s.terrors
+}
+
+func (s *strictChecker) terror(n *node, format string, args ...interface{}) {
+	s.terrors = append(s.terrors, fmt.Sprintf("line %d, column %d: ", n.line+1, n.column+1)+fmt.Sprintf(format, args...))
+}
+
+// check inspects n, which is decoded into a value of type t. A nil t
+// stands for a value whose type is not known ahead of decoding, in which
+// case only duplicated keys are looked for.
+func (s *strictChecker) check(n *node, t reflect.Type) {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t != nil && (reflect.PtrTo(t).Implements(unmarshalerType) || reflect.PtrTo(t).Implements(nodeUnmarshalerType)) {
+		// The value decides by itself what it decodes into.
+		t = nil
+	}
+	switch n.kind {
+	case documentNode:
+		s.doc = n
+		for _, c := range n.children {
+			s.check(c, t)
+		}
+	case aliasNode:
+		an := s.doc.anchors[n.value]
+		if an == nil || s.aliases[an] {
+			return
+		}
+		s.aliases[an] = true
+		s.check(an, t)
+		delete(s.aliases, an)
+	case sequenceNode:
+		var et reflect.Type
+		if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+			et = t.Elem()
+		}
+		for _, c := range n.children {
+			s.check(c, et)
+		}
+	case mappingNode:
+		s.duplicates(n)
+		s.mapping(n, t)
+	}
+}
+
+func (s *strictChecker) mapping(n *node, t reflect.Type) {
+	var sinfo *structInfo
+	var et reflect.Type
+	if t != nil {
+		switch t.Kind() {
+		case reflect.Struct:
+			var err error
+			if sinfo, err = getStructInfo(t); err != nil {
+				panic(err)
+			}
+			if sinfo.InlineMap != -1 {
+				et = t.Field(sinfo.InlineMap).Type.Elem()
+			}
+		case reflect.Map:
+			et = t.Elem()
+		}
+	}
+	for i := 0; i+1 < len(n.children); i += 2 {
+		k, v := n.children[i], n.children[i+1]
+		if isMerge(k) {
+			s.merge(v, t)
+			continue
+		}
+		if sinfo == nil {
+			s.check(k, nil)
+			s.check(v, et)
+			continue
+		}
+		if k.kind != scalarNode {
+			// Reported by the decoder as a type mismatch.
+			continue
+		}
+		if info, ok := sinfo.FieldsMap[k.value]; ok {
+			if info.Inline == nil {
+				s.check(v, t.Field(info.Num).Type)
+			} else {
+				s.check(v, t.FieldByIndex(info.Inline).Type)
+			}
+		} else if sinfo.InlineMap != -1 || sinfo.InlineSlice != -1 {
+			s.check(v, et)
+		} else {
+			s.terror(k, "field %s not found in type %s", k.value, t)
+		}
+	}
+}
+
+// merge checks the mappings merged into a mapping decoded into type t.
+func (s *strictChecker) merge(n *node, t reflect.Type) {
+	switch n.kind {
+	case mappingNode:
+		s.mapping(n, t)
+	case aliasNode:
+		if an := s.doc.anchors[n.value]; an != nil && !s.aliases[an] {
+			s.aliases[an] = true
+			s.merge(an, t)
+			delete(s.aliases, an)
+		}
+	case sequenceNode:
+		for _, c := range n.children {
+			s.merge(c, t)
+		}
+	}
+}
+
+// duplicates reports the keys of mapping n that are set more than once.
+// Keys are compared by their resolved values, so that 1 and 0x1 clash
+// while "1" and 1 do not.
+func (s *strictChecker) duplicates(n *node) {
+	seen := make(map[interface{}]bool)
+	for i := 0; i+1 < len(n.children); i += 2 {
+		k := n.children[i]
+		if k.kind != scalarNode || isMerge(k) {
+			continue
+		}
+		tag, resolved := yaml_STR_TAG, interface{}(k.value)
+		if k.tag != "" || k.implicit {
+			tag, resolved = resolve(k.tag, k.value)
+		}
+		key := MapItem{tag, resolved}
+		if seen[key] {
+			s.terror(k, "key %#v already set in map", k.value)
+		}
+		seen[key] = true
+	}
+}
+
+var unmarshalerType = reflect.TypeOf((*Unmarshaler)(nil)).Elem()
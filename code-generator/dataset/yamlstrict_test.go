@@ -0,0 +1,83 @@
+package yaml_test
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+
+	. "gopkg.in/check.v1"
+	"gopkg.in/yaml.v2"
+)
+
+var unmarshalStrictTests = []struct {
+	data  string
+	value interface{}
+	error string
+}{{
+	data:  "a: 1\nc: 2\n",
+	value: struct{ A, B int }{A: 1},
+	error: "yaml: unmarshal errors:\n  line 2, column 1: field c not found in type struct { A int; B int }",
+}, {
+	data:  "a: 1\nb: 2\na: 3\n",
+	value: struct{ A, B int }{A: 3, B: 2},
+	error: "yaml: unmarshal errors:\n  line 3, column 1: key \"a\" already set in map",
+}, {
+	data:  "c:\n  1: x\n  0x1: y\n",
+	value: struct{ C map[int]string }{C: map[int]string{1: "y"}},
+	error: "yaml: unmarshal errors:\n  line 3, column 3: key \"0x1\" already set in map",
+}, {
+	data:  "a: 1\n'a': 2\n",
+	value: map[string]int{"a": 2},
+	error: "yaml: unmarshal errors:\n  line 2, column 1: key \"a\" already set in map",
+}, {
+	data:  "a: [{b: 1, c: 2}]\nd: x\n",
+	value: struct{ A []struct{ B int } }{A: []struct{ B int }{{B: 1}}},
+	error: "yaml: unmarshal errors:\n" +
+		"  line 1, column 12: field c not found in type struct { B int }\n" +
+		"  line 2, column 1: field d not found in type struct { A []struct { B int } }",
+}, {
+	data:  "a: x\nb: 2\n",
+	value: struct{ A, B int }{B: 2},
+	error: "yaml: unmarshal errors:\n  line 1, column 4: cannot unmarshal !!str `x` into int",
+}}
+
+func (s *S) TestUnmarshalStrictErrors(c *C) {
+	for i, item := range unmarshalStrictTests {
+		c.Logf("test %d: %q", i, item.data)
+		value := reflect.New(reflect.TypeOf(item.value))
+		err := yaml.UnmarshalStrict([]byte(item.data), value.Interface())
+		c.Assert(err, ErrorMatches, regexp.QuoteMeta(item.error))
+		c.Assert(value.Elem().Interface(), DeepEquals, item.value)
+
+		// Unmarshal ignores the very same problems.
+		err = yaml.Unmarshal([]byte(item.data), reflect.New(reflect.TypeOf(item.value)).Interface())
+		if !strings.Contains(item.error, "cannot unmarshal") {
+			c.Assert(err, IsNil)
+		}
+	}
+}
+
+func (s *S) TestUnmarshalStrictAccepts(c *C) {
+	type Inner struct{ B int }
+	var v struct {
+		Inner `yaml:",inline"`
+		A     int
+		M     map[string]int `yaml:",inline"`
+	}
+	data := "base: &base {b: 1}\nother:\n  <<: *base\n  a: 2\n"
+	var m map[string]struct {
+		A, B int
+	}
+	c.Assert(yaml.UnmarshalStrict([]byte(data), &m), IsNil)
+	c.Assert(m["other"].B, Equals, 1)
+	c.Assert(yaml.UnmarshalStrict([]byte("a: 1\nb: 2\nx: 3\n"), &v), IsNil)
+	c.Assert(v.M, DeepEquals, map[string]int{"x": 3})
+}
+
+func (s *S) TestDecoderStrict(c *C) {
+	dec := yaml.NewDecoder(strings.NewReader("a: 1\n---\nb: 2\n"))
+	dec.SetStrict(true)
+	var v struct{ A int }
+	c.Assert(dec.Decode(&v), IsNil)
+	c.Assert(dec.Decode(&v), ErrorMatches, "yaml: unmarshal errors:\n  line 3, column 1: field b not found in type .*")
+}
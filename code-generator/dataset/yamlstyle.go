@@ -0,0 +1,133 @@
+package yaml
+
+import (
+	"regexp"
+	"strings"
+)
+
+// restyle applies the flow and quoting settings of the encoder to the
+// node tree n, which is about to be emitted.
+func (e *Encoder) restyle(n *Node) {
+	switch n.Kind {
+	case SequenceNode, MappingNode:
+		if e.flow&n.Kind != 0 {
+			n.Style |= FlowStyle
+		}
+	case ScalarNode:
+		if e.quote && n.Style == 0 && n.ShortTag() == "!!str" && isAmbiguous(n.Value) {
+			n.Style = DoubleQuotedStyle
+		}
+	}
+	for _, c := range n.Content {
+		e.restyle(c)
+	}
+}
+
+// isAmbiguous reports whether s, which this package reads back as a plain
+// string, is read as a different type of value by other YAML parsers,
+// be it as a YAML 1.1 boolean, timestamp or sexagesimal number, or as a
+// YAML 1.2 number.
+func isAmbiguous(s string) bool {
+	if s == "" {
+		return false
+	}
+	switch strings.ToLower(s) {
+	case "y", "n", "yes", "no", "on", "off", "true", "false", "null", "~", "=":
+		return true
+	}
+	return ambiguousPlain.MatchString(s) || isBase60Float(s)
+}
+
+var ambiguousPlain = regexp.MustCompile(`^(?:` +
+	`[-+]?\.(?i:inf|nan)` + // Floats in any letter case.
+	`|0o[0-7]+` + // YAML 1.2 octal integers.
+	`|[-+]?(?:\.[0-9]+|[0-9]+(?:\.[0-9]*)?)[eE][-+]?[0-9]+` + // YAML 1.2 floats with an unsigned exponent.
+	`|[0-9]{4}-[0-9]{1,2}-[0-9]{1,2}(?:[Tt ].*)?` + // Timestamps.
+	`)$`)
+
+// emitNode writes the node tree n through the emitter, which unlike the
+// node printer folds long lines and honours the configured indentation.
+// Comments are not written.
+func (e *encoder) emitNode(n *Node) {
+	switch n.Kind {
+	case DocumentNode:
+		for _, c := range n.Content {
+			e.emitNode(c)
+		}
+	case SequenceNode:
+		tag := longTag(n.Tag)
+		implicit := n.Style&TaggedStyle == 0 && (tag == "" || tag == yaml_SEQ_TAG)
+		style := yaml_BLOCK_SEQUENCE_STYLE
+		if n.Style&FlowStyle != 0 {
+			style = yaml_FLOW_SEQUENCE_STYLE
+		}
+		e.must(yaml_sequence_start_event_initialize(&e.event, []byte(n.Anchor), []byte(tag), implicit, style))
+		e.emit()
+		for _, c := range n.Content {
+			e.emitNode(c)
+		}
+		e.must(yaml_sequence_end_event_initialize(&e.event))
+		e.emit()
+	case MappingNode:
+		tag := longTag(n.Tag)
+		implicit := n.Style&TaggedStyle == 0 && (tag == "" || tag == yaml_MAP_TAG)
+		style := yaml_BLOCK_MAPPING_STYLE
+		if n.Style&FlowStyle != 0 {
+			style = yaml_FLOW_MAPPING_STYLE
+		}
+		e.must(yaml_mapping_start_event_initialize(&e.event, []byte(n.Anchor), []byte(tag), implicit, style))
+		e.emit()
+		for _, c := range n.Content {
+			e.emitNode(c)
+		}
+		e.must(yaml_mapping_end_event_initialize(&e.event))
+		e.emit()
+	case ScalarNode:
+		var style yaml_scalar_style_t
+		switch {
+		case n.Style&DoubleQuotedStyle != 0:
+			style = yaml_DOUBLE_QUOTED_SCALAR_STYLE
+		case n.Style&SingleQuotedStyle != 0:
+			style = yaml_SINGLE_QUOTED_SCALAR_STYLE
+		case n.Style&LiteralStyle != 0:
+			style = yaml_LITERAL_SCALAR_STYLE
+		case n.Style&FoldedStyle != 0:
+			style = yaml_FOLDED_SCALAR_STYLE
+		default:
+			style = yaml_PLAIN_SCALAR_STYLE
+		}
+		tag := longTag(n.Tag)
+		rtag, _ := resolve("", n.Value)
+		plain := tag == "" || tag == rtag
+		quoted := tag == "" || tag == yaml_STR_TAG
+		if n.Style&TaggedStyle != 0 {
+			plain, quoted = false, false
+		}
+		e.must(yaml_scalar_event_initialize(&e.event, []byte(n.Anchor), []byte(tag), []byte(n.Value), plain, quoted, style))
+		e.emit()
+	case AliasNode:
+		anchor := n.Value
+		if n.Alias != nil && n.Alias.Anchor != "" {
+			anchor = n.Alias.Anchor
+		}
+		e.event = yaml_event_t{typ: yaml_ALIAS_EVENT, anchor: []byte(anchor)}
+		e.emit()
+	default:
+		failf("cannot encode node with unknown kind %d", n.Kind)
+	}
+}
+
+// setLayout sets the indentation and line width of the documents emitted
+// next, as described in Encoder.SetIndent and Encoder.SetWidth.
+func (e *encoder) setLayout(indent, width int) {
+	if indent == 0 {
+		indent = 2
+	}
+	if width == 0 || width > 0 && width <= indent*2 {
+		width = 80
+	} else if width < 0 {
+		width = 1<<31 - 1
+	}
+	e.emitter.best_indent = indent
+	e.emitter.best_width = width
+}
@@ -0,0 +1,108 @@
+package yaml_test
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+
+	. "gopkg.in/check.v1"
+	"gopkg.in/yaml.v2"
+)
+
+type styleConfig struct {
+	Name  string
+	Hosts []string
+	Ports map[string]int
+	Tags  []string `yaml:",flow"`
+}
+
+var styleValue = styleConfig{
+	Name:  "web",
+	Hosts: []string{"a", "b"},
+	Ports: map[string]int{"http": 80, "https": 443},
+	Tags:  []string{"x"},
+}
+
+var encoderStyleTests = []struct {
+	configure func(enc *yaml.Encoder)
+	value     interface{}
+	data      string
+}{{
+	func(enc *yaml.Encoder) {},
+	styleValue,
+	"name: web\nhosts:\n- a\n- b\nports:\n  http: 80\n  https: 443\ntags: [x]\n",
+}, {
+	func(enc *yaml.Encoder) { enc.SetIndent(4) },
+	styleValue,
+	"name: web\nhosts:\n- a\n- b\nports:\n    http: 80\n    https: 443\ntags: [x]\n",
+}, {
+	func(enc *yaml.Encoder) { enc.SetFlowStyle(yaml.SequenceNode) },
+	styleValue,
+	"name: web\nhosts: [a, b]\nports:\n  http: 80\n  https: 443\ntags: [x]\n",
+}, {
+	func(enc *yaml.Encoder) { enc.SetFlowStyle(yaml.MappingNode) },
+	map[string]interface{}{"a": map[interface{}]interface{}{"b": 1}, "c": []interface{}{1}},
+	"{a: {b: 1}, c: [1]}\n",
+}, {
+	func(enc *yaml.Encoder) { enc.SetFlowStyle(yaml.SequenceNode); enc.SetIndent(3) },
+	map[string]map[string][]int{"a": {"b": {1, 2}}},
+	"a:\n   b: [1, 2]\n",
+}, {
+	func(enc *yaml.Encoder) { enc.SetWidth(20) },
+	map[string]string{"a": "one two three four five six"},
+	"a: one two three four\n  five six\n",
+}, {
+	func(enc *yaml.Encoder) { enc.SetWidth(-1) },
+	map[string]string{"a": strings.Repeat("word ", 30) + "end"},
+	"a: " + strings.Repeat("word ", 30) + "end\n",
+}, {
+	func(enc *yaml.Encoder) { enc.SetQuoteAmbiguous(true) },
+	[]string{"no", "nO", "oN", "yEs", "=", "0o17", "2001-12-14", ".InF", "1e3", "-2.5E-3", "1:20", "nothing", "1.2.3", "1e", "1:2:3:"},
+	"- \"no\"\n- \"nO\"\n- \"oN\"\n- \"yEs\"\n- \"=\"\n- \"0o17\"\n- \"2001-12-14\"\n- \".InF\"\n- \"1e3\"\n- \"-2.5E-3\"\n- \"1:20\"\n- nothing\n- 1.2.3\n- 1e\n- '1:2:3:'\n",
+}, {
+	func(enc *yaml.Encoder) {},
+	[]string{"nO", "oN", "2001-12-14"},
+	"- nO\n- oN\n- 2001-12-14\n",
+}, {
+	func(enc *yaml.Encoder) { enc.SetQuoteAmbiguous(true) },
+	map[string]interface{}{"oN": true, "n": 1, "b": "\xff"},
+	"b: !!binary /w==\n\"n\": 1\n\"oN\": true\n",
+}}
+
+func (s *S) TestEncoderStyles(c *C) {
+	for i, item := range encoderStyleTests {
+		c.Logf("test %d: %#v", i, item.value)
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		item.configure(enc)
+		err := enc.Encode(item.value)
+		c.Assert(err, IsNil)
+		c.Assert(enc.Close(), IsNil)
+		c.Assert(buf.String(), Equals, item.data)
+
+		// The output decodes back into the same value.
+		out := reflect.New(reflect.TypeOf(item.value))
+		err = yaml.Unmarshal(buf.Bytes(), out.Interface())
+		c.Assert(err, IsNil)
+		c.Assert(out.Elem().Interface(), DeepEquals, item.value)
+	}
+}
+
+func (s *S) TestEncoderIndentRange(c *C) {
+	enc := yaml.NewEncoder(&bytes.Buffer{})
+	c.Assert(func() { enc.SetIndent(1) }, PanicMatches, "yaml: indentation must be between 2 and 9 spaces")
+	c.Assert(func() { enc.SetIndent(10) }, PanicMatches, "yaml: indentation must be between 2 and 9 spaces")
+}
+
+func (s *S) TestEncoderStylesAcrossDocuments(c *C) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	c.Assert(enc.Encode([]int{1}), IsNil)
+	enc.SetFlowStyle(yaml.SequenceNode)
+	c.Assert(enc.Encode([]int{2}), IsNil)
+	enc.SetFlowStyle(0)
+	enc.SetIndent(4)
+	c.Assert(enc.Encode(map[string]map[string]int{"a": {"b": 3}}), IsNil)
+	c.Assert(enc.Close(), IsNil)
+	c.Assert(buf.String(), Equals, "- 1\n--- [2]\n---\na:\n    b: 3\n")
+}
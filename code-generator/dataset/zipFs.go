@@ -0,0 +1,35 @@
+package afero
+
+import (
+	"archive/zip"
+	"io/ioutil"
+)
+
+// The ZipFs is a read only Fs serving the files of a zip archive, such as
+// one opened with zip.OpenReader, without extracting them. The files are
+// read into memory when they are opened.
+type ZipFs struct {
+	*archiveFs
+}
+
+func NewZipFs(r *zip.Reader) Fs {
+	z := &ZipFs{archiveFs: newArchiveFs()}
+	for _, f := range r.File {
+		f := f
+		z.add(f.Name, &archiveEntry{
+			info: f.FileInfo(),
+			content: func() ([]byte, error) {
+				rc, err := f.Open()
+				if err != nil {
+					return nil, err
+				}
+				defer rc.Close()
+				return ioutil.ReadAll(rc)
+			},
+		})
+	}
+	z.sort()
+	return z
+}
+
+func (z *ZipFs) Name() string { return "ZipFs" }